@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-test-maga/validator"
+)
+
+const annotationPrefix = "# yamlvalid: "
+
+// annotateResults annotates each file with findings; see annotateFile.
+// Files read from stdin or from an archive are not on disk under their
+// name, so they are skipped rather than annotating an unrelated file.
+func annotateResults(results []validator.FileResult, inPlace bool) []error {
+	var errs []error
+	for _, r := range results {
+		if len(r.Errors) == 0 || r.FromStdin || r.Archive != "" {
+			continue
+		}
+		if _, err := annotateFile(r.File, r.Errors, inPlace); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// annotateFile writes the findings back into the YAML source as comments
+// placed above the offending lines. Unless inPlace is set the result goes
+// to a sibling file, e.g. pod.yaml -> pod.annotated.yaml. It returns the
+// path that was written. Annotations of an earlier run are replaced, so
+// annotating a file again does not stack them up.
+func annotateFile(path string, errs []validator.ValidationError, inPlace bool) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(data), "\n")
+
+	byLine := make(map[int][]string)
+	for _, e := range errs {
+		byLine[e.Line] = append(byLine[e.Line], e.Message)
+	}
+
+	var out []string
+	// Findings without a line refer to the whole document
+	for _, msg := range byLine[0] {
+		out = append(out, annotationPrefix+msg)
+	}
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(trimmed, strings.TrimSpace(annotationPrefix)) {
+			continue
+		}
+		indent := line[:len(line)-len(trimmed)]
+		// Keep list markers aligned: "  - name: x" gets its comment at the
+		// column of the dash
+		for _, msg := range byLine[i+1] {
+			out = append(out, indent+annotationPrefix+msg)
+		}
+		out = append(out, line)
+	}
+
+	target := path
+	if !inPlace {
+		ext := filepath.Ext(path)
+		target = strings.TrimSuffix(path, ext) + ".annotated" + ext
+	}
+	return target, os.WriteFile(target, []byte(strings.Join(out, "\n")), 0o644)
+}
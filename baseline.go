@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"go-test-maga/validator"
+)
+
+// A baseline is a snapshot of known findings. Entries are keyed by file,
+// rule and message but not by line, so a baselined finding stays
+// suppressed when unrelated edits shift it up or down.
+type baselineEntry struct {
+	File    string `json:"file"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func baselineKey(e validator.ValidationError) baselineEntry {
+	return baselineEntry{File: e.File, Rule: e.Rule, Message: e.Message}
+}
+
+func loadBaseline(path string) (map[baselineEntry]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []baselineEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	known := make(map[baselineEntry]bool, len(entries))
+	for _, e := range entries {
+		known[e] = true
+	}
+	return known, nil
+}
+
+// writeBaseline records the findings of results, sorted and without
+// duplicates so that the file diffs well when it is updated.
+func writeBaseline(path string, results []validator.FileResult) error {
+	seen := make(map[baselineEntry]bool)
+	entries := []baselineEntry{}
+	for _, r := range results {
+		for _, e := range r.Errors {
+			if k := baselineKey(e); !seen[k] {
+				seen[k] = true
+				entries = append(entries, k)
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Message < b.Message
+	})
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// notInBaseline keeps only findings that are not part of the baseline,
+// recording in matched the entries that still occur.
+func notInBaseline(known, matched map[baselineEntry]bool) func(validator.ValidationError) bool {
+	return func(e validator.ValidationError) bool {
+		k := baselineKey(e)
+		if known[k] {
+			matched[k] = true
+			return false
+		}
+		return true
+	}
+}
+
+// staleBaselineEntries counts the entries for the files of results that
+// no longer occur: findings fixed since the baseline was written. Entries
+// of files not validated in this run are not counted.
+func staleBaselineEntries(known, matched map[baselineEntry]bool, results []validator.FileResult) int {
+	files := make(map[string]bool, len(results))
+	for _, r := range results {
+		files[r.File] = true
+	}
+	stale := 0
+	for k := range known {
+		if files[k.File] && !matched[k] {
+			stale++
+		}
+	}
+	return stale
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-test-maga/validator"
+)
+
+const baselinePod = "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n    - name: web\n      image: nginx:1.25\n      ports:\n        - containerPort: 70000\n"
+
+// findingMessages returns the messages of every finding of res.
+func findingMessages(res validator.Result) []string {
+	var msgs []string
+	for _, r := range res.Files {
+		for _, e := range r.Errors {
+			msgs = append(msgs, e.Message)
+		}
+	}
+	return msgs
+}
+
+func TestBaselineRoundTrip(t *testing.T) {
+	paths := writeFiles(t, "pod.yaml", baselinePod, "svc.yaml", "apiVersion: v1\nkind: Service\nmetadata:\n  name: s\n")
+	baseline := filepath.Join(filepath.Dir(paths[0]), "baseline.json")
+	first := (&validator.Validator{}).ValidatePaths(paths...)
+	if len(findingMessages(first)) < 2 {
+		t.Fatalf("want findings in both files, got %q", findingMessages(first))
+	}
+	if err := writeBaseline(baseline, first.Files); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Index(string(data), "pod.yaml") > strings.Index(string(data), "svc.yaml") {
+		t.Errorf("baseline entries are not sorted by file:\n%s", data)
+	}
+
+	validate := func() (validator.Result, int) {
+		t.Helper()
+		known, err := loadBaseline(baseline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matched := make(map[baselineEntry]bool)
+		v := &validator.Validator{Filters: []func(validator.ValidationError) bool{notInBaseline(known, matched)}}
+		res := v.ValidatePaths(paths...)
+		return res, staleBaselineEntries(known, matched, res.Files)
+	}
+	if res, stale := validate(); len(findingMessages(res)) != 0 || stale != 0 {
+		t.Fatalf("against its own baseline: got %q and %d stale entries", findingMessages(res), stale)
+	}
+
+	// Moving a finding to another line keeps it baselined, a new one is
+	// reported
+	edited := "# moved down\n" + strings.Replace(baselinePod, "nginx:1.25", "nginx:1.25\n      imagePullPolicy: Sometimes", 1)
+	if err := os.WriteFile(paths[0], []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, _ := validate()
+	if msgs := findingMessages(res); len(msgs) != 1 || !strings.Contains(msgs[0], "Sometimes") {
+		t.Fatalf("after edit: got %q, want only the imagePullPolicy finding", msgs)
+	}
+
+	// Fixing a baselined finding leaves its entry stale
+	if err := os.WriteFile(paths[0], []byte(strings.Replace(baselinePod, "70000", "8080", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if res, stale := validate(); len(findingMessages(res)) != 0 || stale != 1 {
+		t.Fatalf("after fix: got %q and %d stale entries, want none and 1", findingMessages(res), stale)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-test-maga/validator"
+)
+
+// runFix implements yamlvalid fix: it applies the fixes of the findings
+// in the given files in place, or prints them as a diff with --dry-run,
+// and returns the exit code. args are the arguments after "fix".
+func runFix(v *validator.Validator, args []string) int {
+	fs := flag.NewFlagSet("fix", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the fixes as a unified diff to stdout instead of writing the files")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "fix needs at least one file, directory or -")
+		return exitUsage
+	}
+	if v.SubstituteEnv {
+		fmt.Fprintln(os.Stderr, "fix cannot be combined with --substitute-env")
+		return exitUsage
+	}
+	code := exitOK
+	for _, path := range fixPaths(fs.Args()) {
+		if err := fixFile(v, path, *dryRun); err != nil {
+			fmt.Fprintf(os.Stderr, "Error fixing file: %v\n", err)
+			code = exitIOError
+		}
+	}
+	return code
+}
+
+// fixPaths expands directories to the YAML files below them, skipping
+// hidden directories like validation does.
+func fixPaths(paths []string) []string {
+	var files []string
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil || !fi.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				files = append(files, path)
+			case d.IsDir() && path != p && strings.HasPrefix(d.Name(), "."):
+				return filepath.SkipDir
+			case !d.IsDir() && isYAMLName(path):
+				files = append(files, path)
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// fixFile fixes one file, reporting each fix on stderr. "-" reads stdin
+// and writes the fixed document to stdout.
+func fixFile(v *validator.Validator, path string, dryRun bool) error {
+	name := path
+	var data []byte
+	var err error
+	if path == "-" {
+		name = v.StdinFilename
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	fixed, findings := v.FixData(data, name)
+	for _, e := range findings {
+		fmt.Fprintf(os.Stderr, "%sfixed: %s\n", e.Location(), e.Text())
+	}
+	switch {
+	case dryRun:
+		writeDiff(os.Stdout, name, data, fixed)
+		return nil
+	case path == "-":
+		_, err = os.Stdout.Write(fixed)
+		return err
+	case bytes.Equal(data, fixed):
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, fixed, fi.Mode().Perm())
+}
+
+// writeDiff prints a unified diff of old and new with three lines of
+// context. Fixes never add or remove lines, so lines are compared one to
+// one.
+func writeDiff(w io.Writer, name string, old, new []byte) {
+	const context = 3
+	a, b := diffLines(old), diffLines(new)
+	if len(a) != len(b) || bytes.Equal(old, new) {
+		return
+	}
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", name, name)
+	for i := 0; i < len(a); {
+		if a[i] == b[i] {
+			i++
+			continue
+		}
+		// Changes less than two contexts apart share a hunk
+		last := i
+		for j := i + 1; j < len(a) && j <= last+2*context; j++ {
+			if a[j] != b[j] {
+				last = j
+			}
+		}
+		start, end := max(0, i-context), min(len(a), last+context+1)
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", start+1, end-start, start+1, end-start)
+		for j := start; j < end; {
+			if a[j] == b[j] {
+				writeDiffLine(w, " ", a[j])
+				j++
+				continue
+			}
+			k := j
+			for k < end && a[k] != b[k] {
+				k++
+			}
+			for _, l := range a[j:k] {
+				writeDiffLine(w, "-", l)
+			}
+			for _, l := range b[j:k] {
+				writeDiffLine(w, "+", l)
+			}
+			j = k
+		}
+		i = end
+	}
+}
+
+func diffLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func writeDiffLine(w io.Writer, prefix, line string) {
+	fmt.Fprint(w, prefix+line)
+	if !strings.HasSuffix(line, "\n") {
+		fmt.Fprint(w, "\n\\ No newline at end of file\n")
+	}
+}
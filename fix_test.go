@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-test-maga/validator"
+)
+
+func TestFixFile(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("validator", "testdata", "fix", "input.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("validator", "testdata", "fix", "want.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "pod.yaml")
+	if err := os.WriteFile(path, input, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v := &validator.Validator{}
+	for run := 1; run <= 2; run++ {
+		if err := fixFile(v, path, false); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("run %d: file differs from want.yaml:\n%s", run, got)
+		}
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("file mode not kept: %v %v", fi.Mode(), err)
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	old := []byte("a: 1\nb: 2\nc: 3\nd: 4\ne: 5\n")
+	new := []byte("a: 1\nb: \"2\"\nc: 3\nd: 4\ne: 5\n")
+	var got bytes.Buffer
+	writeDiff(&got, "x.yaml", old, new)
+	want := "--- x.yaml\n+++ x.yaml\n@@ -1,5 +1,5 @@\n a: 1\n-b: 2\n+b: \"2\"\n c: 3\n d: 4\n e: 5\n"
+	if got.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", got.String(), want)
+	}
+
+	got.Reset()
+	writeDiff(&got, "x.yaml", old, old)
+	if got.Len() != 0 {
+		t.Errorf("diff of unchanged data: %q", got.String())
+	}
+}
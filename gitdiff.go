@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"go-test-maga/validator"
+)
+
+var hunkRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// changedLines reports the lines of a file added or modified since ref,
+// according to git. A nil set with all=true means every line counts as
+// changed, e.g. for files git does not track yet.
+func changedLines(ref, path string) (lines map[int]bool, all bool, err error) {
+	if exec.Command("git", "ls-files", "--error-unmatch", "--", path).Run() != nil {
+		return nil, true, nil
+	}
+	out, err := exec.Command("git", "diff", "-U0", "--no-color", ref, "--", path).Output()
+	if err != nil {
+		return nil, false, err
+	}
+	lines = make(map[int]bool)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		m := hunkRe.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		for i := start; i < start+count; i++ {
+			lines[i] = true
+		}
+	}
+	return lines, false, nil
+}
+
+// onChangedLines keeps only findings on lines changed since ref. Findings
+// without a line are kept when their file changed at all. Files whose
+// diff cannot be computed are passed through unfiltered.
+func onChangedLines(ref string) func(validator.ValidationError) bool {
+	type fileDiff struct {
+		lines map[int]bool
+		all   bool
+	}
+	cache := make(map[string]fileDiff)
+	return func(e validator.ValidationError) bool {
+		d, ok := cache[e.File]
+		if !ok {
+			lines, all, err := changedLines(ref, e.File)
+			d = fileDiff{lines: lines, all: all || err != nil}
+			cache[e.File] = d
+		}
+		if d.all {
+			return true
+		}
+		if e.Line == 0 {
+			return len(d.lines) > 0
+		}
+		return d.lines[e.Line]
+	}
+}
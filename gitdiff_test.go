@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-test-maga/validator"
+)
+
+// git runs a git command in the current directory.
+func git(t *testing.T, args ...string) {
+	t.Helper()
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// git diff runs in the working directory, so move into the repository
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	git(t, "init", "-q")
+	git(t, "config", "user.email", "test@example.com")
+	git(t, "config", "user.name", "test")
+	if err := os.WriteFile("pod.yaml", []byte(baselinePod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, "add", "pod.yaml")
+	git(t, "commit", "-q", "--no-gpg-sign", "-m", "pod")
+
+	// A second container with its own bad port, and a file git does not
+	// track yet
+	added := "    - name: sidecar\n      image: envoy:1.29\n      ports:\n        - containerPort: 70001\n"
+	if err := os.WriteFile("pod.yaml", []byte(baselinePod+added), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("new.yaml", []byte(baselinePod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	v := &validator.Validator{Filters: []func(validator.ValidationError) bool{onChangedLines("HEAD")}}
+	var got []string
+	for _, r := range v.ValidatePaths("pod.yaml", "new.yaml").Files {
+		for _, e := range r.Errors {
+			got = append(got, strings.TrimSpace(e.Location()))
+		}
+	}
+	if want := []string{"pod.yaml:14:26", "new.yaml:10:26"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got findings at %q, want %q", got, want)
+	}
+}
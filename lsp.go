@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go-test-maga/validator"
+)
+
+// serveLSP speaks the Language Server Protocol on r and w, publishing the
+// findings of every open document as diagnostics whenever it is opened
+// or edited, and offering the fixes of findings as quick fixes. Only
+// full-text synchronization is supported, which is plenty for manifests.
+// It returns when the client sends exit; the error tells whether the
+// client shut the server down first, as the protocol asks.
+func serveLSP(v *validator.Validator, r io.Reader, w io.Writer) error {
+	in := bufio.NewReader(r)
+	shutdown := false
+	for {
+		msg, err := readLSPMessage(in)
+		if err == io.EOF {
+			return fmt.Errorf("client closed the connection")
+		}
+		if err != nil {
+			return err
+		}
+		switch msg.Method {
+		case "initialize":
+			err = replyLSP(w, msg.ID, map[string]any{
+				"capabilities": map[string]any{
+					"textDocumentSync":   map[string]any{"openClose": true, "change": 1},
+					"codeActionProvider": map[string]any{"codeActionKinds": []string{"quickfix"}},
+				},
+				"serverInfo": map[string]any{"name": "yamlvalid", "version": version},
+			})
+		case "textDocument/didOpen":
+			var p struct {
+				TextDocument struct {
+					URI  string `json:"uri"`
+					Text string `json:"text"`
+				} `json:"textDocument"`
+			}
+			if json.Unmarshal(msg.Params, &p) == nil {
+				err = publishDiagnostics(w, v, p.TextDocument.URI, p.TextDocument.Text)
+			}
+		case "textDocument/didChange":
+			var p struct {
+				TextDocument struct {
+					URI string `json:"uri"`
+				} `json:"textDocument"`
+				ContentChanges []struct {
+					Text string `json:"text"`
+				} `json:"contentChanges"`
+			}
+			if json.Unmarshal(msg.Params, &p) == nil && len(p.ContentChanges) > 0 {
+				text := p.ContentChanges[len(p.ContentChanges)-1].Text
+				err = publishDiagnostics(w, v, p.TextDocument.URI, text)
+			}
+		case "textDocument/didClose":
+			var p struct {
+				TextDocument struct {
+					URI string `json:"uri"`
+				} `json:"textDocument"`
+			}
+			if json.Unmarshal(msg.Params, &p) == nil {
+				err = notifyLSP(w, "textDocument/publishDiagnostics", map[string]any{
+					"uri": p.TextDocument.URI, "diagnostics": []lspDiagnostic{},
+				})
+			}
+		case "textDocument/codeAction":
+			var p struct {
+				TextDocument struct {
+					URI string `json:"uri"`
+				} `json:"textDocument"`
+				Context struct {
+					Diagnostics []lspDiagnostic `json:"diagnostics"`
+				} `json:"context"`
+			}
+			if err = json.Unmarshal(msg.Params, &p); err != nil {
+				err = writeLSPMessage(w, lspMessage{ID: msg.ID, Error: &lspError{Code: -32602, Message: err.Error()}})
+				break
+			}
+			err = replyLSP(w, msg.ID, quickFixes(p.TextDocument.URI, p.Context.Diagnostics))
+		case "shutdown":
+			shutdown = true
+			err = replyLSP(w, msg.ID, nil)
+		case "exit":
+			if !shutdown {
+				return fmt.Errorf("exit without shutdown")
+			}
+			return nil
+		default:
+			// Notifications we do not handle are dropped; requests must
+			// be answered
+			if msg.ID != nil {
+				err = writeLSPMessage(w, lspMessage{ID: msg.ID, Error: &lspError{Code: -32601, Message: "method not found: " + msg.Method}})
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// lspMessage is a JSON-RPC request, response or notification.
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+	// Data carries the fix of the finding, so code actions need not
+	// validate the document again
+	Data *lspTextEdit `json:"data,omitempty"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+// quickFixes turns the fixes of the diagnostics the client asks about
+// into code actions.
+func quickFixes(uri string, diags []lspDiagnostic) []map[string]any {
+	actions := []map[string]any{}
+	for _, d := range diags {
+		if d.Source != "yamlvalid" || d.Data == nil {
+			continue
+		}
+		actions = append(actions, map[string]any{
+			"title":       fmt.Sprintf("Replace with %s", d.Data.NewText),
+			"kind":        "quickfix",
+			"diagnostics": []lspDiagnostic{d},
+			"isPreferred": true,
+			"edit": map[string]any{
+				"changes": map[string][]lspTextEdit{uri: {*d.Data}},
+			},
+		})
+	}
+	return actions
+}
+
+// readLSPMessage reads one message framed by a Content-Length header.
+func readLSPMessage(r *bufio.Reader) (*lspMessage, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, _ := strings.Cut(line, ":")
+		if strings.EqualFold(name, "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid Content-Length '%s'", strings.TrimSpace(value))
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without Content-Length")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	var msg lspMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("invalid message: %v", err)
+	}
+	return &msg, nil
+}
+
+func writeLSPMessage(w io.Writer, msg lspMessage) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// replyLSP answers the request id; a nil result is sent as null, since
+// a response must have one.
+func replyLSP(w io.Writer, id json.RawMessage, result any) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return writeLSPMessage(w, lspMessage{ID: id, Result: b})
+}
+
+func notifyLSP(w io.Writer, method string, params any) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeLSPMessage(w, lspMessage{Method: method, Params: b})
+}
+
+// publishDiagnostics validates text and sends its findings for uri. Each
+// diagnostic spans the token the finding points at, like the caret of
+// the pretty output.
+func publishDiagnostics(w io.Writer, v *validator.Validator, uri, text string) error {
+	filename := uri
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		filename = u.Path
+	}
+	lines := strings.Split(text, "\n")
+	diags := []lspDiagnostic{}
+	for _, e := range v.ValidateBytes([]byte(text), filename) {
+		d := lspDiagnostic{Code: e.Rule, Source: "yamlvalid", Message: e.Message, Severity: 1}
+		switch e.Severity {
+		case validator.SeverityWarning:
+			d.Severity = 2
+		case validator.SeverityInfo:
+			d.Severity = 3
+		}
+		if e.Path != "" {
+			d.Message = e.Path + ": " + e.Message
+		}
+		if f := e.Fix; f != nil {
+			d.Data = &lspTextEdit{NewText: f.Replacement, Range: lspRange{
+				Start: lspPosition{Line: f.Line - 1, Character: f.Column - 1},
+				End:   lspPosition{Line: f.EndLine - 1, Character: f.EndColumn - 1},
+			}}
+		}
+		if e.Line > 0 {
+			start := lspPosition{Line: e.Line - 1}
+			end := start
+			if e.Column > 0 {
+				start.Character = e.Column - 1
+				end.Character = start.Character
+			}
+			if e.Line <= len(lines) {
+				line := strings.TrimRight(lines[e.Line-1], "\r")
+				if _, width, ok := caretAt(line, e.Column); ok {
+					end.Character = start.Character + width
+				} else if e.Column == 0 {
+					end.Character = len([]rune(line))
+				}
+			}
+			d.Range.Start, d.Range.End = start, end
+		}
+		diags = append(diags, d)
+	}
+	return notifyLSP(w, "textDocument/publishDiagnostics", map[string]any{
+		"uri": uri, "diagnostics": diags,
+	})
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-test-maga/validator"
+)
+
+// lspClient drives serveLSP over pipes like an editor would.
+type lspClient struct {
+	t    *testing.T
+	in   io.Writer
+	out  *bufio.Reader
+	done chan error
+}
+
+func startLSP(t *testing.T) *lspClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &lspClient{t: t, in: inW, out: bufio.NewReader(outR), done: make(chan error, 1)}
+	go func() {
+		err := serveLSP(&validator.Validator{}, inR, outW)
+		// Fail the client's reads and writes rather than block them
+		inR.Close()
+		outW.Close()
+		c.done <- err
+	}()
+	return c
+}
+
+func (c *lspClient) send(id int, method string, params any) {
+	c.t.Helper()
+	msg := lspMessage{Method: method}
+	if id > 0 {
+		msg.ID = json.RawMessage(strconv.Itoa(id))
+	}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		msg.Params = b
+	}
+	if err := writeLSPMessage(c.in, msg); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *lspClient) receive(v any) *lspMessage {
+	c.t.Helper()
+	msg, err := readLSPMessage(c.out)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	raw := msg.Result
+	if msg.Method != "" {
+		raw = msg.Params
+	}
+	if v != nil {
+		if err := json.Unmarshal(raw, v); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	return msg
+}
+
+func TestServeLSP(t *testing.T) {
+	c := startLSP(t)
+	c.send(1, "initialize", map[string]any{})
+	var initialized struct {
+		Capabilities struct {
+			TextDocumentSync struct {
+				Change int `json:"change"`
+			} `json:"textDocumentSync"`
+		} `json:"capabilities"`
+	}
+	c.receive(&initialized)
+	if initialized.Capabilities.TextDocumentSync.Change != 1 {
+		t.Errorf("got change %d, want full sync", initialized.Capabilities.TextDocumentSync.Change)
+	}
+
+	uri := "file:///work/pod.yaml"
+	text := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\ndata:\n  retries: 3\n"
+	c.send(0, "textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "text": text}})
+	var published struct {
+		URI         string          `json:"uri"`
+		Diagnostics []lspDiagnostic `json:"diagnostics"`
+	}
+	c.receive(&published)
+	if published.URI != uri || len(published.Diagnostics) != 1 {
+		t.Fatalf("got %+v, want one diagnostic for %s", published, uri)
+	}
+	d := published.Diagnostics[0]
+	want := lspRange{Start: lspPosition{Line: 5, Character: 11}, End: lspPosition{Line: 5, Character: 12}}
+	if d.Code != "CM002" || d.Severity != 1 || d.Range != want || d.Data == nil || d.Data.NewText != `"3"` {
+		t.Fatalf("got diagnostic %+v", d)
+	}
+
+	c.send(2, "textDocument/codeAction", map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"context":      map[string]any{"diagnostics": published.Diagnostics},
+	})
+	var actions []struct {
+		Kind string `json:"kind"`
+		Edit struct {
+			Changes map[string][]lspTextEdit `json:"changes"`
+		} `json:"edit"`
+	}
+	c.receive(&actions)
+	if len(actions) != 1 || actions[0].Kind != "quickfix" || len(actions[0].Edit.Changes[uri]) != 1 || actions[0].Edit.Changes[uri][0] != *d.Data {
+		t.Fatalf("got code actions %+v", actions)
+	}
+
+	// Fixing the document clears its diagnostics
+	c.send(0, "textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": uri},
+		"contentChanges": []map[string]any{{"text": strings.Replace(text, "3", `"3"`, 1)}},
+	})
+	c.receive(&published)
+	if len(published.Diagnostics) != 0 {
+		t.Errorf("got diagnostics %+v after the fix", published.Diagnostics)
+	}
+
+	c.send(3, "workspace/symbol", map[string]any{})
+	if msg := c.receive(nil); msg.Error == nil || msg.Error.Code != -32601 {
+		t.Errorf("unknown request: got %+v, want method not found", msg)
+	}
+	c.send(4, "shutdown", nil)
+	if msg := c.receive(nil); !bytes.Equal(msg.Result, []byte("null")) {
+		t.Errorf("shutdown: got result %s, want null", msg.Result)
+	}
+	c.send(0, "exit", nil)
+	if err := <-c.done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServeLSPExitWithoutShutdown(t *testing.T) {
+	c := startLSP(t)
+	c.send(0, "exit", nil)
+	if err := <-c.done; err == nil {
+		t.Fatal("exit without shutdown succeeded")
+	}
+}
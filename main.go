@@ -1,143 +1,622 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
-	"strconv"
+	"runtime/pprof"
+	"strings"
+	"time"
 
-	"gopkg.in/yaml.v3"
+	"go-test-maga/validator"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <yaml-file>\n", os.Args[0])
-		os.Exit(1)
+	ruleConfig := flag.String("rule-config", "", "`file` mapping rule ids to error, warning, info or off")
+	configPath := flag.String("config", "", "project config `file` (default: "+validator.ConfigFileName+" in the current directory or a parent)")
+	format := flag.String("format", "", "output format: `text`, pretty, json, summary-json or jsonl (default: pretty when writing to a terminal, text otherwise)")
+	substEnv := flag.Bool("substitute-env", false, "expand ${VAR} placeholders from the environment before parsing")
+	lenient := flag.Bool("lenient", false, "accept and normalize values that only differ in letter case")
+	countByFile := flag.Bool("count-by-file", false, "print the number of errors per file to stdout, worst first")
+	explain := flag.String("explain-rule", "", "describe the rule with the given `id` and exit")
+	maxCPU := flag.Int("max-cpu", 64, "warn when cpu exceeds this many `cores` (0 disables)")
+	maxMemory := flag.String("max-memory", "256Gi", "warn when memory exceeds this `quantity` (0 disables)")
+	strict := flag.Bool("strict", false, "enable opinionated best-practice checks and report unknown fields")
+	normalize := flag.Bool("normalize", false, "print the manifests in canonical form to stdout instead of validating them")
+	showVersion := flag.Bool("version", false, "print the tool and ruleset version and exit")
+	pinnedRules := flag.Int("rules-version", 0, "fail unless the built-in ruleset has this `version`")
+	softPin := flag.Bool("rules-version-soft", false, "only warn when --rules-version does not match")
+	annotate := flag.Bool("annotate", false, "write findings as comments into a copy of each file (name.annotated.yaml; not for stdin or archive entries)")
+	inPlace := flag.Bool("in-place", false, "with --annotate, modify the original files instead of writing copies")
+	wrapWidth := flag.Int("wrap-width", -1, "wrap messages at `N` columns (default: terminal width, no wrapping when not a terminal)")
+	nameMatchesFile := flag.Bool("name-matches-filename", false, "warn when metadata.name does not contain a token derived from the file name")
+	nameTransform := flag.String("name-filename-transform", validator.TransformFirstSegment, "how the token is derived: `first-segment` or basename")
+	checkConfig := flag.Bool("check-config", false, "validate the project config and --rule-config files and exit without validating manifests")
+	perFile := flag.Bool("output-per-file", false, "write a report next to each validated file (not for stdin or archive entries)")
+	reportSuffix := flag.String("report-suffix", ".report.json", "file name `suffix` for --output-per-file reports")
+	reportFormat := flag.String("report-format", "json", "format of --output-per-file reports: `json` or text")
+	color := flag.String("color", "auto", "colorize output: `auto`, always or never")
+	maxFileSize := flag.String("max-file-size", "10Mi", "skip inputs larger than this `quantity` of bytes (0 disables)")
+	baseline := flag.String("baseline", "", "suppress findings recorded in this baseline `file`")
+	newBaseline := flag.String("write-baseline", "", "record all current findings to this baseline `file`")
+	changedSince := flag.String("changed-since", "", "only report findings on lines changed since this git `ref`")
+	allowedNamespaces := flag.String("allowed-namespaces", "", "comma-separated `list` of namespaces manifests may target")
+	requireNamespace := flag.Bool("require-namespace", false, "with --allowed-namespaces, require metadata.namespace instead of assuming default")
+	var kinds stringList
+	flag.Var(&kinds, "kind", "only validate documents of this `kind`, skipping others (repeatable)")
+	quantityUnits := flag.String("quantity-units", "", "comma-separated `units` resource quantities may use, e.g. m,Mi,Gi; an empty entry allows plain numbers")
+	nameStyle := flag.String("container-name-style", validator.NameStyleRFC1123, "container name style: `rfc1123` or snake_case")
+	tagPolicy := flag.String("image-tag-policy", validator.TagPolicyAny, "how images must be pinned: `any`, no-latest or digest-only")
+	var allowedRegistries stringList
+	flag.Var(&allowedRegistries, "allowed-registries", "only allow images from this `registry`, e.g. registry.example.com or *.example.com (repeatable)")
+	var schemaDirs, schemaURLs, crdDirs stringList
+	flag.Var(&schemaDirs, "schema-dir", "also check documents against the JSON Schema or OpenAPI files in this `dir` (repeatable)")
+	flag.Var(&schemaURLs, "schema-url", "also check documents against the OpenAPI document at this `url`, e.g. a cluster's /openapi/v2 (repeatable)")
+	flag.Var(&crdDirs, "crd-dir", "check custom resources against the CustomResourceDefinitions in the YAML files below this `dir` (repeatable)")
+	policyDir := flag.String("policy-dir", "", "evaluate the Rego policies in this `dir` against every document; needs the opa binary")
+	policyNamespace := flag.String("policy-namespace", "main", "Rego `package` whose deny and warn rules are reported")
+	var enabledRules stringList
+	flag.Var(&enabledRules, "enable-rule", "enable an opt-in rule by `id` (repeatable)")
+	reportPassing := flag.Bool("report-passing", false, "when validating several files, print \"OK: file\" to stdout for each file without errors")
+	stdinFilename := flag.String("stdin-filename", validator.StdinName, "file `name` to report for input read from stdin (-)")
+	warningsAsErrors := flag.Bool("warnings-as-errors", false, "fail the run on warnings as well as errors")
+	summary := flag.Bool("summary", false, "print statistics after the findings: files, documents, findings by severity and rule, elapsed time; in summary-json output they are included, with json and jsonl they go to stderr as JSON")
+	failFast := flag.Bool("fail-fast", false, "stop at the first error")
+	maxFindings := flag.Int("max-findings", 0, "print at most `N` findings in text and pretty output, then how many more there are (0: no limit)")
+	maxErrors := flag.Int("max-errors", 0, "only fail the run when more than `N` errors are found")
+	tieredExit := flag.Bool("tiered-exit", false, "exit 1 when only warnings were found and 2 on errors")
+	jobs := flag.Int("jobs", 0, "validate `N` files at once (default: the number of CPUs)")
+	profile := flag.String("profile", "", "write a CPU profile of the validation to this `file`, for go tool pprof")
+	watch := flag.Bool("watch", false, "validate again whenever a YAML file below the given paths changes, until interrupted")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <yaml-file | dir | ->...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s [flags] fix [--dry-run] <yaml-file | dir | ->...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s [flags] lsp\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s [flags] serve [--webhook] [--listen addr] [--tls-cert file --tls-key file]\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprint(os.Stderr, exitCodeHelp)
+		fmt.Fprint(os.Stderr, baselineHelp)
 	}
-	filePath := os.Args[1]
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-		os.Exit(1)
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("yamlvalid %s (ruleset %d)\n", version, validator.RulesetVersion)
+		return
 	}
 
-	var root yaml.Node
-	if err := yaml.Unmarshal(data, &root); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing YAML: %v\n", err)
-		os.Exit(1)
+	if *pinnedRules != 0 && *pinnedRules != validator.RulesetVersion {
+		if *softPin {
+			fmt.Fprintf(os.Stderr, "warning: ruleset version is %d, pinned %d; results may differ\n", validator.RulesetVersion, *pinnedRules)
+		} else {
+			fmt.Fprintf(os.Stderr, "Ruleset version is %d, but --rules-version=%d was requested\n", validator.RulesetVersion, *pinnedRules)
+			os.Exit(exitUsage)
+		}
 	}
 
-	// Determine root mapping node
-	var mapping *yaml.Node
-	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
-		mapping = root.Content[0]
-	} else {
-		mapping = &root
+	if *configPath == "" {
+		*configPath = validator.FindConfig(".")
 	}
 
-	var errs []string
+	if *explain != "" {
+		r := validator.FindRule(*explain)
+		if r == nil && *configPath != "" {
+			// A rule from the customRules section of the config
+			if cfg, cfgErrs := validator.LoadConfig(*configPath); len(cfgErrs) == 0 {
+				for _, cr := range cfg.CustomRules {
+					if cr.ID == *explain {
+						r = &cr.Rule
+					}
+				}
+			}
+		}
+		if r == nil {
+			fmt.Fprintf(os.Stderr, "Unknown rule id '%s'\n", *explain)
+			os.Exit(exitUsage)
+		}
+		validator.ExplainRule(os.Stdout, r)
+		return
+	}
 
-	// Find spec node and validate fields
-	specNode := findMapKey(mapping, "spec")
-	if specNode != nil && specNode.Kind == yaml.MappingNode {
-		// Validate spec.os
-		errs = append(errs, validateOS(specNode, filePath)...)
+	if *color != "auto" && *color != "always" && *color != "never" {
+		fmt.Fprintf(os.Stderr, "Unsupported color mode '%s'\n", *color)
+		os.Exit(exitUsage)
+	}
 
-		// Validate each container in spec.containers
-		conts := findMapKey(specNode, "containers")
-		if conts != nil && conts.Kind == yaml.SequenceNode {
-			for _, contNode := range conts.Content {
-				if contNode.Kind != yaml.MappingNode {
-					continue
-				}
-				// readinessProbe.httpGet.port validation
-				errs = append(errs, validateHTTPGetPort(contNode, filePath)...)
-				// resources.requests.cpu validation
-				errs = append(errs, validateCPU(contNode, filePath)...)
+	if *reportFormat != "json" && *reportFormat != formatText {
+		fmt.Fprintf(os.Stderr, "Unsupported report format '%s'\n", *reportFormat)
+		os.Exit(exitUsage)
+	}
+
+	if *checkConfig {
+		if *ruleConfig == "" && *configPath == "" {
+			fmt.Fprintln(os.Stderr, "--check-config requires --rule-config or a config file")
+			os.Exit(exitUsage)
+		}
+		ok, cfgErrs := checkConfigFiles(*configPath, *ruleConfig)
+		for _, path := range ok {
+			fmt.Printf("%s: OK\n", path)
+		}
+		for _, e := range cfgErrs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		if len(cfgErrs) > 0 {
+			os.Exit(exitFailed)
+		}
+		return
+	}
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+
+	if *normalize {
+		// Several files come out as one stream, like kubectl's output
+		for i, path := range flag.Args() {
+			if i > 0 {
+				fmt.Fprintln(os.Stdout, "---")
+			}
+			if err := validator.NormalizeFile(path, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error normalizing %s: %v\n", path, err)
+				os.Exit(exitIOError)
 			}
 		}
+		return
 	}
 
-	// Print errors to stderr
-	for _, e := range errs {
-		fmt.Fprintln(os.Stderr, e)
+	if *format == "" {
+		// Findings are written to stderr, so that is the stream that
+		// decides whether a person is reading them
+		*format = formatText
+		if isTerminal(os.Stderr) {
+			*format = formatPretty
+		}
+	}
+	if *watch {
+		for _, p := range flag.Args() {
+			if p == "-" {
+				fmt.Fprintln(os.Stderr, "--watch cannot read from stdin")
+				os.Exit(exitUsage)
+			}
+		}
 	}
-	if len(errs) > 0 {
-		os.Exit(1)
+
+	if !validFormat(*format) {
+		fmt.Fprintf(os.Stderr, "Unsupported format '%s'\n", *format)
+		os.Exit(exitUsage)
 	}
-}
 
-func findMapKey(node *yaml.Node, key string) *yaml.Node {
-	if node == nil || node.Kind != yaml.MappingNode {
-		return nil
+	if !validator.ValidNameStyle(*nameStyle) {
+		fmt.Fprintf(os.Stderr, "Unsupported container name style '%s'\n", *nameStyle)
+		os.Exit(exitUsage)
 	}
-	// Mapping node Content has [key0, val0, key1, val1, ...]
-	for i := 0; i < len(node.Content); i += 2 {
-		k := node.Content[i]
-		if k.Kind == yaml.ScalarNode && k.Value == key {
-			return node.Content[i+1]
+
+	for _, pattern := range allowedRegistries {
+		if !validator.ValidRegistryPattern(pattern) {
+			fmt.Fprintf(os.Stderr, "Invalid registry pattern '%s'\n", pattern)
+			os.Exit(exitUsage)
 		}
 	}
-	return nil
-}
 
-func validateOS(specNode *yaml.Node, filename string) []string {
-	var errs []string
-	osNode := findMapKey(specNode, "os")
-	if osNode != nil {
-		if osNode.Kind == yaml.ScalarNode {
-			if osNode.Value != "linux" && osNode.Value != "windows" {
-				errs = append(errs, fmt.Sprintf("%s:%d os has unsupported value '%s'", filename, osNode.Line, osNode.Value))
+	if !validator.ValidTagPolicy(*tagPolicy) {
+		fmt.Fprintf(os.Stderr, "Unsupported image tag policy '%s'\n", *tagPolicy)
+		os.Exit(exitUsage)
+	}
+
+	if !validator.ValidNameTransform(*nameTransform) {
+		fmt.Fprintf(os.Stderr, "Unsupported name transform '%s'\n", *nameTransform)
+		os.Exit(exitUsage)
+	}
+
+	if *maxFindings < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid --max-findings %d\n", *maxFindings)
+		os.Exit(exitUsage)
+	}
+
+	if *maxErrors < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid --max-errors %d\n", *maxErrors)
+		os.Exit(exitUsage)
+	}
+
+	maxMemoryBytes, ok := validator.ParseMemory(*maxMemory)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Invalid --max-memory quantity '%s'\n", *maxMemory)
+		os.Exit(exitUsage)
+	}
+
+	maxFileBytes, ok := validator.ParseMemory(*maxFileSize)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Invalid --max-file-size quantity '%s'\n", *maxFileSize)
+		os.Exit(exitUsage)
+	}
+
+	// The project config supplies defaults; flags given on the command
+	// line and --rule-config severities take precedence over it
+	cfg := &validator.Config{Severities: map[string]string{}}
+	if *configPath != "" {
+		var cfgErrs []string
+		cfg, cfgErrs = validator.LoadConfig(*configPath)
+		for _, e := range cfgErrs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		if len(cfgErrs) > 0 {
+			os.Exit(exitUsage)
+		}
+	}
+	for _, r := range cfg.CustomRules {
+		validator.RegisterRule(r.Rule, r.Check)
+	}
+	for _, id := range enabledRules {
+		if validator.FindRule(id) == nil {
+			fmt.Fprintf(os.Stderr, "Unknown rule id '%s'\n", id)
+			os.Exit(exitUsage)
+		}
+	}
+	severities := cfg.Severities
+	if *ruleConfig != "" {
+		overrides, cfgErrs := validator.LoadRuleConfig(*ruleConfig)
+		for _, e := range cfgErrs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		if len(cfgErrs) > 0 {
+			os.Exit(exitUsage)
+		}
+		for id, sev := range overrides {
+			severities[id] = sev
+		}
+	}
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	if cfg.MaxCPU != nil && !setFlags["max-cpu"] {
+		*maxCPU = *cfg.MaxCPU
+	}
+	if cfg.MaxMemory != nil && !setFlags["max-memory"] {
+		maxMemoryBytes = *cfg.MaxMemory
+	}
+	if cfg.AllowedNamespaces != nil && !setFlags["allowed-namespaces"] {
+		*allowedNamespaces = strings.Join(cfg.AllowedNamespaces, ",")
+	}
+	if cfg.RequireNamespace != nil && !setFlags["require-namespace"] {
+		*requireNamespace = *cfg.RequireNamespace
+	}
+	if cfg.AllowedRegistries != nil && !setFlags["allowed-registries"] {
+		allowedRegistries = cfg.AllowedRegistries
+	}
+	if cfg.QuantityUnits != nil && !setFlags["quantity-units"] {
+		*quantityUnits = strings.Join(cfg.QuantityUnits, ",")
+	}
+	if cfg.ContainerNameStyle != "" && !setFlags["container-name-style"] {
+		*nameStyle = cfg.ContainerNameStyle
+	}
+	if cfg.ImageTagPolicy != "" && !setFlags["image-tag-policy"] {
+		*tagPolicy = cfg.ImageTagPolicy
+	}
+	if cfg.NameTransform != "" && !setFlags["name-filename-transform"] {
+		*nameMatchesFile = true
+		*nameTransform = cfg.NameTransform
+	}
+
+	v := &validator.Validator{
+		Severities:    severities,
+		EnabledRules:  enabledRules,
+		SubstituteEnv: *substEnv,
+		Lenient:       *lenient,
+		Kinds:         kinds,
+		MaxCPU:        *maxCPU,
+		MaxMemory:     maxMemoryBytes,
+		Strict:        *strict,
+		MaxFileSize:   maxFileBytes,
+		StdinFilename: *stdinFilename,
+		Jobs:          *jobs,
+		FailFast:      *failFast,
+	}
+	v.AllowedRegistries = allowedRegistries
+	v.ImageTagPolicy = *tagPolicy
+	v.ContainerNameStyle = *nameStyle
+	if setFlags["quantity-units"] || *quantityUnits != "" {
+		v.QuantityUnits = strings.Split(*quantityUnits, ",")
+	}
+	if *allowedNamespaces != "" {
+		for _, ns := range strings.Split(*allowedNamespaces, ",") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				v.AllowedNamespaces = append(v.AllowedNamespaces, ns)
 			}
-		} else if osNode.Kind == yaml.MappingNode {
-			nameNode := findMapKey(osNode, "name")
-			if nameNode == nil {
-				errs = append(errs, fmt.Sprintf("%s:%d os.name is required", filename, osNode.Line))
-			} else if nameNode.Kind != yaml.ScalarNode {
-				errs = append(errs, fmt.Sprintf("%s:%d os.name must be string", filename, nameNode.Line))
-			} else if nameNode.Value != "linux" && nameNode.Value != "windows" {
-				errs = append(errs, fmt.Sprintf("%s:%d os has unsupported value '%s'", filename, nameNode.Line, nameNode.Value))
+		}
+		v.RequireNamespace = *requireNamespace
+	}
+	if *nameMatchesFile {
+		v.NameTransform = *nameTransform
+	}
+	if len(schemaDirs) > 0 || len(schemaURLs) > 0 || len(crdDirs) > 0 {
+		v.Schemas = &validator.SchemaSet{}
+		for _, dir := range schemaDirs {
+			if err := v.Schemas.LoadDir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading schemas: %v\n", err)
+				os.Exit(exitUsage)
+			}
+		}
+		for _, url := range schemaURLs {
+			if err := v.Schemas.LoadURL(url); err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading schemas: %v\n", err)
+				os.Exit(exitUsage)
+			}
+		}
+		for _, dir := range crdDirs {
+			if err := v.Schemas.LoadCRDs(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading CRDs: %v\n", err)
+				os.Exit(exitUsage)
 			}
-		} else {
-			errs = append(errs, fmt.Sprintf("%s:%d os must be string or object", filename, osNode.Line))
 		}
 	}
-	return errs
-}
+	if *policyDir != "" {
+		v.Policies = &validator.Policies{Dir: *policyDir, Namespace: *policyNamespace}
+		if err := v.Policies.CheckPolicies(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading policies: %v\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+	// --baseline and --changed-since stack: a finding is reported only if
+	// it is new relative to the baseline and sits on a line changed since
+	// the ref, which is the "problems this PR introduced" view.
+	var known, matched map[baselineEntry]bool
+	if *baseline != "" {
+		var err error
+		if known, err = loadBaseline(*baseline); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading baseline: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		matched = make(map[baselineEntry]bool)
+		v.Filters = append(v.Filters, notInBaseline(known, matched))
+	}
+	if *changedSince != "" {
+		v.Filters = append(v.Filters, onChangedLines(*changedSince))
+	}
+	// Streamed findings are not kept, but these need them afterwards
+	keepFindings := *newBaseline != "" || *annotate || *perFile
+	if *format == formatJSONL && !keepFindings {
+		v.OnFinding = jsonlWriter(os.Stdout)
+	}
 
-func validateHTTPGetPort(contNode *yaml.Node, filename string) []string {
-	var errs []string
-	rpNode := findMapKey(contNode, "readinessProbe")
-	if rpNode != nil && rpNode.Kind == yaml.MappingNode {
-		httpGetNode := findMapKey(rpNode, "httpGet")
-		if httpGetNode != nil && httpGetNode.Kind == yaml.MappingNode {
-			portNode := findMapKey(httpGetNode, "port")
-			if portNode != nil && portNode.Kind == yaml.ScalarNode {
-				// Parse port as int and check range
-				portVal, err := strconv.Atoi(portNode.Value)
-				if err != nil || portVal < 1 || portVal > 65535 {
-					errs = append(errs, fmt.Sprintf("%s:%d port value out of range", filename, portNode.Line))
+	if flag.Arg(0) == "lsp" {
+		// Editors run the server with the flags of the project, e.g.
+		// yamlvalid --strict lsp
+		if err := serveLSP(v, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving LSP: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "fix" {
+		os.Exit(runFix(v, flag.Args()[1:]))
+	}
+
+	if flag.Arg(0) == "serve" {
+		if err := serve(v, flag.Args()[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		return
+	}
+
+	// Text findings go to stderr, machine-readable reports to stdout
+	report := func(res validator.Result, stats *runStats) {
+		switch *format {
+		case formatSummaryJSON:
+			if err := writeSummaryJSON(os.Stdout, res.Files, stats); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+				os.Exit(exitIOError)
+			}
+		case formatJSON:
+			if err := writeJSON(os.Stdout, res.Files); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+				os.Exit(exitIOError)
+			}
+		case formatJSONL:
+			if v.OnFinding == nil {
+				write := jsonlWriter(os.Stdout)
+				for _, r := range res.Files {
+					for _, e := range r.Errors {
+						write(e)
+					}
 				}
 			}
+		case formatPretty:
+			files, more := truncateFindings(res.Files, *maxFindings)
+			writePretty(os.Stderr, files, useColor(*color, os.Stderr))
+			writeMore(os.Stderr, more)
+		default:
+			width := *wrapWidth
+			if width < 0 {
+				width = terminalWidth(os.Stderr)
+			}
+			files, more := truncateFindings(res.Files, *maxFindings)
+			writeText(os.Stderr, files, textStyle{Width: width, Color: useColor(*color, os.Stderr)})
+			writeMore(os.Stderr, more)
+		}
+	}
+
+	if *watch {
+		if err := watchPaths(v, flag.Args(), func(res validator.Result) { report(res, nil) }); err != nil {
+			fmt.Fprintf(os.Stderr, "Error watching files: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		return
+	}
+
+	var res validator.Result
+	start := time.Now()
+	if *profile != "" {
+		var err error
+		if res, err = profileValidation(*profile, v, flag.Args()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing profile: %v\n", err)
+			os.Exit(exitIOError)
+		}
+	} else {
+		res = v.ValidatePaths(flag.Args()...)
+	}
+	var stats *runStats
+	if *summary {
+		stats = newRunStats(res, time.Since(start))
+	}
+	report(res, stats)
+	if res.FileCount > 1 && (*format == formatText || *format == formatPretty) {
+		writeRunSummary(os.Stderr, res)
+	}
+	if stats != nil {
+		switch *format {
+		case formatText, formatPretty:
+			writeStats(os.Stderr, stats)
+		case formatJSON, formatJSONL:
+			json.NewEncoder(os.Stderr).Encode(stats)
 		}
 	}
-	return errs
+
+	if known != nil && *newBaseline == "" {
+		if stale := staleBaselineEntries(known, matched, res.Files); stale > 0 {
+			fmt.Fprintf(os.Stderr, "note: %s in the baseline no longer found; run with --write-baseline to update it\n", plural(stale, "finding"))
+		}
+	}
+
+	if *newBaseline != "" {
+		if err := writeBaseline(*newBaseline, res.Files); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing baseline: %v\n", err)
+			os.Exit(exitIOError)
+		}
+	}
+
+	if *annotate {
+		for _, err := range annotateResults(res.Files, *inPlace) {
+			fmt.Fprintf(os.Stderr, "Error annotating file: %v\n", err)
+		}
+	}
+
+	if *perFile {
+		if err := writeReportFiles(res.Files, *reportSuffix, *reportFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			os.Exit(exitIOError)
+		}
+	}
+
+	if *reportPassing && res.FileCount > 1 {
+		writePassing(os.Stdout, res.Files)
+	}
+
+	if *countByFile {
+		writeCountByFile(os.Stdout, res.Files)
+	}
+
+	os.Exit(exitCode(res, *tieredExit, *warningsAsErrors, *maxErrors))
 }
 
-func validateCPU(contNode *yaml.Node, filename string) []string {
-	var errs []string
-	resNode := findMapKey(contNode, "resources")
-	if resNode != nil && resNode.Kind == yaml.MappingNode {
-		for _, resType := range []string{"limits", "requests"} {
-			section := findMapKey(resNode, resType)
-			if section != nil && section.Kind == yaml.MappingNode {
-				cpuNode := findMapKey(section, "cpu")
-				if cpuNode != nil && cpuNode.Kind == yaml.ScalarNode {
-					if cpuNode.Tag != "!!int" {
-						errs = append(errs, fmt.Sprintf("%s:%d cpu must be int", filename, cpuNode.Line))
-					}
-				}
+// Exit codes; see exitCodeHelp.
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	exitIOError = 3
+)
+
+const exitCodeHelp = `
+Exit codes:
+  0  no errors (warnings only, unless --tiered-exit or --warnings-as-errors)
+  1  errors found; with --tiered-exit, warnings but no errors
+  2  invalid flags, config, schemas or policies, so nothing was validated;
+     with --tiered-exit, also errors found
+  3  a file could not be read, was too large or is not valid YAML, or a
+     report could not be written
+Info findings never change the exit code. --warnings-as-errors counts
+warnings as errors, so with --tiered-exit they exit 2. --max-errors N
+tolerates up to N errors; the run fails only above that. Exit 3 wins over
+every other finding, since an unread file may hide any number of errors.
+`
+
+const baselineHelp = `
+Incremental adoption:
+  --write-baseline b.json records every current finding. --baseline b.json
+  then hides those findings; entries match on file, rule and message, so
+  they survive line shifts. Entries whose findings are gone are counted
+  in a note, so the baseline can be rewritten to lock in the progress.
+  --changed-since REF hides findings on lines not changed since REF
+  (untracked files count as fully changed). Given both, only findings
+  that are new AND on changed lines are reported.
+`
+
+func exitCode(res validator.Result, tiered, warningsAsErrors bool, maxErrors int) int {
+	if res.Count.ReadErrors > 0 {
+		return exitIOError
+	}
+	errors := res.Count.Errors
+	if warningsAsErrors {
+		errors += res.Count.Warnings
+	}
+	failed := errors > maxErrors
+	switch {
+	case !tiered && failed:
+		return exitFailed
+	case tiered && failed:
+		return 2
+	case tiered && (errors > 0 || res.Count.Warnings > 0):
+		return 1
+	}
+	return exitOK
+}
+
+// profileValidation validates paths while recording a CPU profile to
+// path. Only validation is profiled, not loading the config or writing
+// the report.
+func profileValidation(path string, v *validator.Validator, paths []string) (validator.Result, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return validator.Result{}, err
+	}
+	defer f.Close()
+	if err := pprof.StartCPUProfile(f); err != nil {
+		return validator.Result{}, err
+	}
+	res := v.ValidatePaths(paths...)
+	pprof.StopCPUProfile()
+	return res, f.Close()
+}
+
+// stringList collects the values of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// checkConfigFiles loads the config files a run would load, in the same
+// order, and returns the paths of those without problems and the
+// problems found, each led by file:line. The custom rules of a valid
+// project config are registered first, as in a run, since the rule
+// config may set their severity.
+func checkConfigFiles(configPath, ruleConfig string) (ok, errs []string) {
+	if configPath != "" {
+		cfg, cfgErrs := validator.LoadConfig(configPath)
+		if len(cfgErrs) == 0 {
+			ok = append(ok, configPath)
+			for _, r := range cfg.CustomRules {
+				validator.RegisterRule(r.Rule, r.Check)
 			}
 		}
+		errs = append(errs, cfgErrs...)
+	}
+	if ruleConfig != "" {
+		_, cfgErrs := validator.LoadRuleConfig(ruleConfig)
+		if len(cfgErrs) == 0 {
+			ok = append(ok, ruleConfig)
+		}
+		errs = append(errs, cfgErrs...)
 	}
-	return errs
+	return ok, errs
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// customRuleSeq keeps the ids of custom rules registered by tests
+// unique, since rules cannot be unregistered. The rules only apply to
+// the kind TestOnly, so that they do not affect other tests.
+var customRuleSeq int
+
+func TestCheckConfigFiles(t *testing.T) {
+	customRuleSeq++
+	id := fmt.Sprintf("TST%03d", customRuleSeq)
+	paths := writeFiles(t,
+		"project.yaml", "customRules:\n  - id: "+id+"\n    kinds: [TestOnly]\n    field: metadata.labels\n    present: true\n    message: labels are required\n",
+		"severities.yaml", id+": warning\n",
+		"bad-project.yaml", "rules:\n  POD028:\n    allowedRegistries: [\"https://registry.example.com\"]\nglobs: [\"*.yaml\"]\n",
+		"bad-severities.yaml", "NOPE01: warning\n",
+	)
+
+	// The rule config may set the severity of a custom rule of the
+	// project config, as in a run
+	ok, errs := checkConfigFiles(paths[0], paths[1])
+	if len(errs) > 0 || len(ok) != 2 {
+		t.Fatalf("got ok %q and errors %q, want both files ok", ok, errs)
+	}
+
+	ok, errs = checkConfigFiles(paths[2], paths[3])
+	if len(ok) != 0 {
+		t.Errorf("got ok %q, want none", ok)
+	}
+	want := []string{
+		paths[2] + ":4 unknown config key 'globs'",
+		paths[2] + ":3 option allowedRegistries has invalid registry pattern 'https://registry.example.com'",
+		paths[3] + ":1 unknown rule id 'NOPE01'",
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got errors\n%s\nwant\n%s", strings.Join(errs, "\n"), strings.Join(want, "\n"))
+	}
+}
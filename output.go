@@ -0,0 +1,450 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-test-maga/validator"
+)
+
+const (
+	formatText        = "text"
+	formatSummaryJSON = "summary-json"
+	formatJSONL       = "jsonl"
+	formatJSON        = "json"
+	formatPretty      = "pretty"
+)
+
+type fileSummary struct {
+	File       string                      `json:"file"`
+	ErrorCount int                         `json:"errorCount"`
+	Errors     []validator.ValidationError `json:"errors"`
+}
+
+// newFileSummary groups the findings of a file. ErrorCount counts only
+// error-severity findings, matching the run summary; Errors lists all.
+func newFileSummary(file string, errs []validator.ValidationError) fileSummary {
+	if errs == nil {
+		errs = []validator.ValidationError{}
+	}
+	s := fileSummary{File: file, Errors: errs}
+	for _, e := range errs {
+		if e.Severity == validator.SeverityError {
+			s.ErrorCount++
+		}
+	}
+	return s
+}
+
+type batchSummary struct {
+	Files       []fileSummary `json:"files"`
+	TotalErrors int           `json:"totalErrors"`
+	Stats       *runStats     `json:"summary,omitempty"`
+}
+
+// runStats are the statistics printed by --summary.
+type runStats struct {
+	Files     int            `json:"files"`
+	Documents int            `json:"documents"`
+	Errors    int            `json:"errors"`
+	Warnings  int            `json:"warnings"`
+	Infos     int            `json:"infos"`
+	ByRule    map[string]int `json:"byRule"`
+	// Elapsed is the validation time in seconds.
+	Elapsed float64 `json:"elapsedSeconds"`
+}
+
+func newRunStats(res validator.Result, elapsed time.Duration) *runStats {
+	stats := &runStats{
+		Files:     res.FileCount,
+		Documents: res.Documents,
+		Errors:    res.Count.Errors,
+		Warnings:  res.Count.Warnings,
+		Infos:     res.Count.Infos,
+		ByRule:    make(map[string]int),
+		Elapsed:   elapsed.Seconds(),
+	}
+	for rule, n := range res.Count.ByRule {
+		stats.ByRule[rule] = n
+	}
+	return stats
+}
+
+// writeStats prints stats for people, rules with the most findings first.
+func writeStats(w io.Writer, stats *runStats) {
+	fmt.Fprintln(w, "Summary:")
+	fmt.Fprintf(w, "  files:      %d\n", stats.Files)
+	fmt.Fprintf(w, "  documents:  %d\n", stats.Documents)
+	fmt.Fprintf(w, "  errors:     %d\n", stats.Errors)
+	fmt.Fprintf(w, "  warnings:   %d\n", stats.Warnings)
+	fmt.Fprintf(w, "  infos:      %d\n", stats.Infos)
+	ids := make([]string, 0, len(stats.ByRule))
+	for id := range stats.ByRule {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if a, b := stats.ByRule[ids[i]], stats.ByRule[ids[j]]; a != b {
+			return a > b
+		}
+		return ids[i] < ids[j]
+	})
+	for i, id := range ids {
+		label := ""
+		if i == 0 {
+			label = "by rule:"
+		}
+		fmt.Fprintf(w, "  %-11s %-7s %d\n", label, id, stats.ByRule[id])
+	}
+	fmt.Fprintf(w, "  elapsed:    %s\n", time.Duration(stats.Elapsed*float64(time.Second)).Round(time.Millisecond))
+}
+
+func validFormat(f string) bool {
+	return f == formatText || f == formatSummaryJSON || f == formatJSONL || f == formatJSON || f == formatPretty
+}
+
+// jsonlWriter returns a callback writing one JSON object per line. The
+// writer is not buffered, so every line is flushed as it is produced.
+func jsonlWriter(w io.Writer) func(validator.ValidationError) {
+	enc := json.NewEncoder(w)
+	return func(e validator.ValidationError) {
+		enc.Encode(e)
+	}
+}
+
+// textStyle controls how writeText lays out findings.
+type textStyle struct {
+	// Width wraps long messages with a hanging indent aligned under the
+	// message; zero or less disables wrapping.
+	Width int
+	// Color highlights the location and colors messages by severity.
+	Color bool
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// writeText prints one finding per line.
+func writeText(w io.Writer, results []validator.FileResult, style textStyle) {
+	for _, r := range results {
+		for _, e := range r.Errors {
+			loc := e.Location()
+			lines := []string{e.Text()}
+			if style.Width > 0 {
+				lines = wrapWords(e.Text(), style.Width-len(loc))
+			}
+			for i, l := range lines {
+				prefix := loc
+				if i > 0 {
+					prefix = strings.Repeat(" ", len(loc))
+				}
+				if style.Color {
+					prefix = ansiBold + prefix + ansiReset
+					l = severityColor(e.Severity) + l + ansiReset
+				}
+				fmt.Fprintln(w, prefix+l)
+			}
+		}
+	}
+}
+
+// writePretty prints each finding compiler-style, followed by the source
+// line it points at with a caret under the offending token:
+//
+//	error[POD012]: spec.containers[0].ports[0].containerPort: containerPort value 70000 out of range (1-65535)
+//	  --> pod.yaml:13:24
+//	   |
+//	13 |         - containerPort: 70000
+//	   |                          ^^^^^
+//
+// Findings with a fix end with a help line naming the replacement. The
+// source is read back from disk, so input from stdin or an archive gets
+// no excerpt.
+func writePretty(w io.Writer, results []validator.FileResult, color bool) {
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + ansiReset
+	}
+	for _, r := range results {
+		var lines []string
+		if !r.FromStdin && r.Archive == "" {
+			if data, err := os.ReadFile(r.File); err == nil {
+				lines = strings.Split(string(data), "\n")
+			}
+		}
+		for _, e := range r.Errors {
+			severity := e.Severity
+			if severity == "" {
+				severity = validator.SeverityError
+			}
+			label := severity
+			if e.Rule != "" {
+				label += "[" + e.Rule + "]"
+			}
+			msg := e.Message
+			if e.Path != "" {
+				msg = e.Path + ": " + msg
+			}
+			fmt.Fprintf(w, "%s: %s\n", paint(ansiBold+severityColor(e.Severity), label), paint(ansiBold, msg))
+			loc := strings.TrimSuffix(e.Location(), " ")
+			if loc == "" {
+				loc = e.File
+			}
+			if e.Line < 1 || e.Line > len(lines) {
+				fmt.Fprintf(w, "  %s %s\n\n", paint(ansiCyan, "-->"), loc)
+				continue
+			}
+			num := strconv.Itoa(e.Line)
+			gutter := strings.Repeat(" ", len(num))
+			src := strings.TrimRight(lines[e.Line-1], "\r")
+			fmt.Fprintf(w, "%s%s %s\n", gutter, paint(ansiCyan, "-->"), loc)
+			fmt.Fprintf(w, "%s %s\n", gutter, paint(ansiCyan, "|"))
+			fmt.Fprintf(w, "%s %s %s\n", paint(ansiCyan, num), paint(ansiCyan, "|"), src)
+			if pad, token, ok := caretAt(src, e.Column); ok {
+				fmt.Fprintf(w, "%s %s %s%s\n", gutter, paint(ansiCyan, "|"), pad, paint(ansiBold+severityColor(e.Severity), strings.Repeat("^", token)))
+			}
+			if e.Fix != nil {
+				fmt.Fprintf(w, "%s %s %s\n", gutter, paint(ansiCyan, "="), "help: replace with "+e.Fix.Replacement+" (yamlvalid fix does this)")
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// caretAt returns the indentation that puts a caret under the 1-based
+// column col of line, keeping tabs so it lines up in any terminal, and the
+// width of the token there: a quoted string, or a plain scalar up to the
+// next space, flow separator or mapping colon.
+func caretAt(line string, col int) (pad string, width int, ok bool) {
+	runes := []rune(line)
+	if col < 1 || col > len(runes) {
+		return "", 0, false
+	}
+	var b strings.Builder
+	for _, r := range runes[:col-1] {
+		if r == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	rest := runes[col-1:]
+	width = 1
+	switch q := rest[0]; q {
+	case '"', '\'':
+		for width < len(rest) && rest[width] != q {
+			width++
+		}
+		if width < len(rest) {
+			width++
+		}
+	default:
+		for width < len(rest) {
+			r := rest[width]
+			if r == ' ' || r == '\t' || r == ',' || r == ']' || r == '}' ||
+				(r == ':' && (width+1 == len(rest) || rest[width+1] == ' ')) {
+				break
+			}
+			width++
+		}
+	}
+	return b.String(), width, true
+}
+
+func severityColor(severity string) string {
+	switch severity {
+	case validator.SeverityError, "":
+		return ansiRed
+	case validator.SeverityInfo:
+		return ansiCyan
+	}
+	return ansiYellow
+}
+
+// useColor resolves --color: auto colors only terminals and honors
+// NO_COLOR, while an explicit always or never wins over NO_COLOR.
+func useColor(mode string, f *os.File) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(f)
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// wrapWords breaks text into lines of at most width columns, splitting on
+// spaces. Words longer than width are left intact.
+func wrapWords(text string, width int) []string {
+	if width < 20 {
+		width = 20
+	}
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
+
+// terminalWidth returns the width to wrap at when f is a terminal, asking
+// the terminal first and falling back to $COLUMNS, and 0 (no wrapping)
+// otherwise.
+func terminalWidth(f *os.File) int {
+	if !isTerminal(f) {
+		return 0
+	}
+	if cols := ttyWidth(f); cols > 0 {
+		return cols
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return 80
+}
+
+// writeSummaryJSON prints the findings grouped by file, with the --summary
+// statistics when stats is not nil.
+func writeSummaryJSON(w io.Writer, results []validator.FileResult, stats *runStats) error {
+	summary := batchSummary{Files: []fileSummary{}, Stats: stats}
+	for _, r := range results {
+		fs := newFileSummary(r.File, r.Errors)
+		summary.Files = append(summary.Files, fs)
+		summary.TotalErrors += fs.ErrorCount
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(summary)
+}
+
+// writeJSON prints every finding of the run as one JSON array.
+func writeJSON(w io.Writer, results []validator.FileResult) error {
+	all := []validator.ValidationError{}
+	for _, r := range results {
+		all = append(all, r.Errors...)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(all)
+}
+
+// writeCountByFile prints "N\tfile" lines, worst files first, so the
+// output can be piped straight into sort/awk. N counts errors only, like
+// errorCount in summary-json.
+func writeCountByFile(w io.Writer, results []validator.FileResult) {
+	sorted := make([]validator.FileResult, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Count.Errors > sorted[j].Count.Errors
+	})
+	for _, r := range sorted {
+		fmt.Fprintf(w, "%d\t%s\n", r.Count.Errors, r.File)
+	}
+}
+
+// writePassing prints "OK: file" for every file without errors, giving
+// an inventory of what was checked alongside the list of failures.
+func writePassing(w io.Writer, results []validator.FileResult) {
+	for _, r := range results {
+		if r.Count.Errors == 0 {
+			fmt.Fprintf(w, "OK: %s\n", r.File)
+		}
+	}
+}
+
+// truncateFindings keeps the first max findings of results, in order,
+// and returns how many it dropped. A max of 0 keeps everything.
+func truncateFindings(results []validator.FileResult, max int) ([]validator.FileResult, int) {
+	if max <= 0 {
+		return results, 0
+	}
+	var out []validator.FileResult
+	more := 0
+	for _, r := range results {
+		if n := len(r.Errors); n > max {
+			more += n - max
+			r.Errors = r.Errors[:max]
+		}
+		max -= len(r.Errors)
+		out = append(out, r)
+	}
+	return out, more
+}
+
+// writeMore prints how many findings truncateFindings left out.
+func writeMore(w io.Writer, more int) {
+	if more > 0 {
+		fmt.Fprintf(w, "... and %s\n", plural(more, "more finding"))
+	}
+}
+
+// writeRunSummary prints the closing "N files checked, M failed, K errors"
+// line of a multi-file run.
+func writeRunSummary(w io.Writer, res validator.Result) {
+	fmt.Fprintf(w, "%s checked, %d failed, %s\n", plural(res.FileCount, "file"), res.FailedFiles, plural(res.Count.Errors, "error"))
+}
+
+// plural formats a count with its noun, e.g. "1 file" or "2 files".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// writeReportFiles stores each file's findings in a sibling report file,
+// e.g. pod.yaml -> pod.yaml.report.json. Files read from stdin or from an
+// archive have no place on disk and get no report.
+func writeReportFiles(results []validator.FileResult, suffix, format string) error {
+	for _, r := range results {
+		if r.FromStdin || r.Archive != "" {
+			// nothing to put the report next to
+			continue
+		}
+		f, err := os.Create(r.File + suffix)
+		if err != nil {
+			return err
+		}
+		if format == formatText {
+			writeText(f, []validator.FileResult{r}, textStyle{})
+		} else {
+			enc := json.NewEncoder(f)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(newFileSummary(r.File, r.Errors))
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-test-maga/validator"
+)
+
+// writeFiles writes name/content pairs into a temporary directory and
+// returns their paths in order.
+func writeFiles(t *testing.T, files ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i+1 < len(files); i += 2 {
+		path := filepath.Join(dir, files[i])
+		if err := os.WriteFile(path, []byte(files[i+1]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// writeTarGz writes a gzipped tarball of the name/content pairs in
+// entries.
+func writeTarGz(t *testing.T, path string, entries ...string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for i := 0; i+1 < len(entries); i += 2 {
+		hdr := &tar.Header{Name: entries[i], Mode: 0o644, Size: int64(len(entries[i+1])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entries[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestArchiveEntriesStayInArchive names an archive entry after a file on
+// disk: --annotate and --output pretty must not take that file for the
+// entry.
+func TestArchiveEntriesStayInArchive(t *testing.T) {
+	paths := writeFiles(t, "pod.yaml", "# on disk\n")
+	archive := filepath.Join(filepath.Dir(paths[0]), "bundle.tar.gz")
+	writeTarGz(t, archive, paths[0], "apiVersion: v1\nkind: Pod\nmetadata:\n  name: a\n")
+	res := (&validator.Validator{}).ValidatePaths(archive)
+	if len(res.Files) != 1 || res.Files[0].File != paths[0] || res.Files[0].Count.Errors == 0 {
+		t.Fatalf("got %+v, want errors for the archive entry", res.Files)
+	}
+
+	var out bytes.Buffer
+	writePretty(&out, res.Files, false)
+	if strings.Contains(out.String(), "on disk") {
+		t.Errorf("pretty output quotes the file on disk:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "--> "+paths[0]) {
+		t.Errorf("pretty output lacks the entry location:\n%s", out.String())
+	}
+
+	if errs := annotateResults(res.Files, true); errs != nil {
+		t.Fatal(errs)
+	}
+	if data, err := os.ReadFile(paths[0]); err != nil || string(data) != "# on disk\n" {
+		t.Errorf("file on disk was annotated: %q %v", data, err)
+	}
+}
+
+func TestCountByFileCountsErrors(t *testing.T) {
+	paths := writeFiles(t,
+		"warn.yaml", "apiVersion: v1\nkind: Widget\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: Widget\nmetadata:\n  name: b\n",
+		"bad.yaml", "apiVersion: v1\nkind: Pod\nmetadata:\n  name: a\n",
+	)
+	v := &validator.Validator{Severities: map[string]string{"DOC012": validator.SeverityWarning}}
+	res := v.ValidatePaths(paths...)
+	if c := res.Files[0].Count; c.Errors != 0 || c.Warnings != 2 {
+		t.Fatalf("warn.yaml: got %d errors and %d warnings, want 0 and 2", c.Errors, c.Warnings)
+	}
+
+	var got bytes.Buffer
+	writeCountByFile(&got, res.Files)
+	if want := "1\t" + paths[1] + "\n0\t" + paths[0] + "\n"; got.String() != want {
+		t.Fatalf("count-by-file:\ngot  %q\nwant %q", got.String(), want)
+	}
+
+	// The same numbers as errorCount in summary-json
+	var buf bytes.Buffer
+	if err := writeSummaryJSON(&buf, res.Files, nil); err != nil {
+		t.Fatal(err)
+	}
+	var summary batchSummary
+	if err := json.Unmarshal(buf.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range summary.Files {
+		if f.ErrorCount != res.Files[i].Count.Errors {
+			t.Errorf("%s: errorCount %d, count-by-file %d", f.File, f.ErrorCount, res.Files[i].Count.Errors)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"go-test-maga/validator"
+)
+
+// maxRequestBytes bounds request bodies; the API server itself limits
+// objects to about 1.5MiB.
+const maxRequestBytes = 10 << 20
+
+// serve runs the server of yamlvalid serve until it fails. args are the
+// arguments after "serve". It serves an HTTP API for validating manifests
+// and, with --webhook, a validating admission webhook:
+//
+//	POST /validate   YAML body, responds with the findings as JSON; the
+//	                 file name used in findings is the ?filename= parameter
+//	GET  /rules      the rules with their effective severity, as JSON
+//	GET  /healthz    200 while the server is up
+//	POST /admission  AdmissionReview (with --webhook)
+func serve(v *validator.Validator, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := fs.String("listen", "", "`address` to listen on (default :8443 with --tls-cert, :8080 otherwise)")
+	webhook := fs.Bool("webhook", false, "serve a Kubernetes validating admission webhook at /admission")
+	certFile := fs.String("tls-cert", "", "serve HTTPS with this certificate `file`; admission webhooks must use HTTPS")
+	keyFile := fs.String("tls-key", "", "private key `file` of --tls-cert")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument '%s'", fs.Arg(0))
+	}
+	if (*certFile == "") != (*keyFile == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+	if *listen == "" {
+		*listen = ":8080"
+		if *certFile != "" {
+			*listen = ":8443"
+		}
+	}
+
+	mux := serveMux(v, *webhook)
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", *listen)
+	if *certFile != "" {
+		return http.ListenAndServeTLS(*listen, *certFile, *keyFile, mux)
+	}
+	return http.ListenAndServe(*listen, mux)
+}
+
+// serveMux routes the endpoints of serve; /admission only with webhook.
+func serveMux(v *validator.Validator, webhook bool) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/validate", validateHandler(v))
+	mux.Handle("/rules", rulesHandler(v))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	if webhook {
+		mux.Handle("/admission", admissionHandler(v))
+	}
+	return mux
+}
+
+// validateHandler validates the YAML request body, which may hold
+// several documents, and responds with a fileSummary. Findings are not
+// HTTP errors: a manifest with errors is still a 200.
+func validateHandler(v *validator.Validator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		name := r.URL.Query().Get("filename")
+		if name == "" {
+			name = "manifest.yaml"
+		}
+		writeJSONResponse(w, newFileSummary(name, v.ValidateBytes(body, name)))
+	})
+}
+
+// rulesHandler lists the rules, with the severities of the rule config
+// the server was started with.
+func rulesHandler(v *validator.Validator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rules := validator.Rules()
+		for i, rule := range rules {
+			if sev, ok := v.Severities[rule.ID]; ok {
+				rules[i].Severity = sev
+			}
+		}
+		writeJSONResponse(w, rules)
+	})
+}
+
+func writeJSONResponse(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// admissionReview is the part of an admission.k8s.io/v1 AdmissionReview
+// the webhook reads and writes.
+type admissionReview struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Request    *admissionRequest  `json:"request,omitempty"`
+	Response   *admissionResponse `json:"response,omitempty"`
+}
+
+type admissionRequest struct {
+	UID       string `json:"uid"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Operation string `json:"operation"`
+	Kind      struct {
+		Kind string `json:"kind"`
+	} `json:"kind"`
+	Object json.RawMessage `json:"object"`
+}
+
+type admissionResponse struct {
+	UID      string           `json:"uid"`
+	Allowed  bool             `json:"allowed"`
+	Status   *admissionStatus `json:"status,omitempty"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+type admissionStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// admissionHandler validates the object of each AdmissionReview with the
+// rule set and denies it when there are errors, with their messages
+// joined into the denial. Warnings are passed back as admission warnings,
+// which kubectl prints without failing the request.
+func admissionHandler(v *validator.Validator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var review admissionReview
+		if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+			http.Error(w, "request body must be an AdmissionReview with a request", http.StatusBadRequest)
+			return
+		}
+		req := review.Request
+		resp := &admissionResponse{UID: req.UID, Allowed: true}
+		// DELETE has no object to check
+		if len(req.Object) > 0 && string(req.Object) != "null" {
+			// JSON is YAML, so the object is validated as it is
+			name := req.Kind.Kind + "/" + req.Name
+			if req.Namespace != "" {
+				name = req.Kind.Kind + "/" + req.Namespace + "/" + req.Name
+			}
+			var denials []string
+			for _, e := range v.ValidateBytes(req.Object, name) {
+				msg := e.Message
+				if e.Path != "" {
+					msg = e.Path + ": " + msg
+				}
+				if e.Rule != "" {
+					msg = "[" + e.Rule + "] " + msg
+				}
+				switch e.Severity {
+				case validator.SeverityError:
+					denials = append(denials, msg)
+				case validator.SeverityWarning:
+					resp.Warnings = append(resp.Warnings, msg)
+				}
+			}
+			if len(denials) > 0 {
+				resp.Allowed = false
+				resp.Status = &admissionStatus{Code: http.StatusForbidden, Message: strings.Join(denials, "; ")}
+			}
+		}
+		writeJSONResponse(w, admissionReview{APIVersion: review.APIVersion, Kind: review.Kind, Response: resp})
+	})
+}
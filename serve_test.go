@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-test-maga/validator"
+)
+
+// request sends body to the test server and decodes a JSON response into
+// v, returning the status code.
+func request(t *testing.T, srv *httptest.Server, method, path, body string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatalf("%s %s: %v in %s", method, path, err, data)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServe(t *testing.T) {
+	v := &validator.Validator{Severities: map[string]string{"DOC012": validator.SeverityOff}}
+	srv := httptest.NewServer(serveMux(v, false))
+	defer srv.Close()
+
+	if code := request(t, srv, http.MethodGet, "/healthz", "", nil); code != http.StatusOK {
+		t.Errorf("healthz: got %d", code)
+	}
+	if code := request(t, srv, http.MethodGet, "/validate", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /validate: got %d, want 405", code)
+	}
+	if code := request(t, srv, http.MethodPost, "/admission", "{}", nil); code != http.StatusNotFound {
+		t.Errorf("/admission without --webhook: got %d, want 404", code)
+	}
+
+	var summary fileSummary
+	body := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: Widget\nmetadata:\n  name: w\n"
+	if code := request(t, srv, http.MethodPost, "/validate?filename=pod.yaml", body, &summary); code != http.StatusOK {
+		t.Fatalf("POST /validate: got %d", code)
+	}
+	if summary.File != "pod.yaml" || summary.ErrorCount != 1 || len(summary.Errors) != 1 {
+		t.Fatalf("got %+v, want the one error of the pod", summary)
+	}
+	if e := summary.Errors[0]; e.File != "pod.yaml" || e.Line != 1 || e.Rule == "" {
+		t.Errorf("got finding %+v", e)
+	}
+
+	var rules []validator.Rule
+	if code := request(t, srv, http.MethodGet, "/rules", "", &rules); code != http.StatusOK {
+		t.Fatalf("GET /rules: got %d", code)
+	}
+	for _, r := range rules {
+		if r.ID == "DOC012" && r.Severity != validator.SeverityOff {
+			t.Errorf("DOC012: got severity %s, want the configured off", r.Severity)
+		}
+	}
+	if len(rules) == 0 {
+		t.Error("no rules")
+	}
+}
+
+func TestAdmissionWebhook(t *testing.T) {
+	v := &validator.Validator{Severities: map[string]string{"DOC012": validator.SeverityWarning}}
+	srv := httptest.NewServer(serveMux(v, true))
+	defer srv.Close()
+
+	review := func(object string) string {
+		return `{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview", "request": {"uid": "42", "name": "a", "namespace": "ns", "operation": "CREATE", "kind": {"kind": "Pod"}, "object": ` + object + `}}`
+	}
+	for _, tc := range []struct {
+		name     string
+		object   string
+		allowed  bool
+		denial   string
+		warnings int
+	}{
+		{
+			name:    "valid",
+			object:  `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "a", "labels": {"app": "a"}}, "spec": {"containers": [{"name": "a", "image": "nginx:1.25", "resources": {"limits": {"cpu": "1", "memory": "1Gi"}, "requests": {"cpu": "1", "memory": "1Gi"}}}]}}`,
+			allowed: true,
+		},
+		{
+			name:   "denied",
+			object: `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "a"}}`,
+			denial: "spec is required",
+		},
+		{
+			name:     "warnings only",
+			object:   `{"apiVersion": "v1", "kind": "Widget", "metadata": {"name": "a"}}`,
+			allowed:  true,
+			warnings: 1,
+		},
+		{
+			name:    "delete",
+			object:  `null`,
+			allowed: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got admissionReview
+			if code := request(t, srv, http.MethodPost, "/admission", review(tc.object), &got); code != http.StatusOK {
+				t.Fatalf("got %d", code)
+			}
+			resp := got.Response
+			if got.Kind != "AdmissionReview" || resp == nil || resp.UID != "42" {
+				t.Fatalf("got %+v, want a response to uid 42", got)
+			}
+			if resp.Allowed != tc.allowed {
+				t.Errorf("allowed %v, want %v: %+v", resp.Allowed, tc.allowed, resp.Status)
+			}
+			if tc.denial != "" && (resp.Status == nil || resp.Status.Code != http.StatusForbidden || !strings.Contains(resp.Status.Message, tc.denial)) {
+				t.Errorf("got status %+v, want a 403 mentioning %q", resp.Status, tc.denial)
+			}
+			if tc.allowed && len(resp.Warnings) != tc.warnings {
+				t.Errorf("got warnings %q, want %d", resp.Warnings, tc.warnings)
+			}
+		})
+	}
+
+	if code := request(t, srv, http.MethodPost, "/admission", `{"kind": "AdmissionReview"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("review without request: got %d, want 400", code)
+	}
+}
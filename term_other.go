@@ -0,0 +1,10 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos)
+
+package main
+
+import "os"
+
+// ttyWidth is not implemented here; terminalWidth falls back to $COLUMNS.
+func ttyWidth(f *os.File) int {
+	return 0
+}
@@ -0,0 +1,61 @@
+package validator
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func isArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+func isYAMLFile(path string) bool {
+	return strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")
+}
+
+// validateArchive streams a gzipped tarball and validates every YAML entry,
+// using the entry path as the file name.
+func (v *Validator) validateArchive(path string) []FileResult {
+	fail := func(err error) []FileResult {
+		return []FileResult{{File: path, Errors: []ValidationError{{File: path, Rule: "DOC002", Message: fmt.Sprintf("Error reading archive: %v", err)}}}}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fail(err)
+	}
+	defer gz.Close()
+
+	var results []FileResult
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return append(results, fail(err)...)
+		}
+		if hdr.Typeflag != tar.TypeReg || !isYAMLFile(hdr.Name) {
+			continue
+		}
+		data, errs := v.readInput(tr, hdr.Name, hdr.Size)
+		if errs != nil {
+			results = append(results, FileResult{File: hdr.Name, Errors: errs, Archive: path})
+			continue
+		}
+		res := v.validateData(data, hdr.Name)
+		res.Archive = path
+		results = append(results, res)
+	}
+	return results
+}
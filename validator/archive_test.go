@@ -0,0 +1,80 @@
+package validator
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTarGz writes a gzipped tarball of the name/content pairs in
+// entries; names ending in / become directories.
+func writeTarGz(t *testing.T, path string, entries ...string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for i := 0; i+1 < len(entries); i += 2 {
+		hdr := &tar.Header{Name: entries[i], Mode: 0o644, Size: int64(len(entries[i+1])), Typeflag: tar.TypeReg}
+		if entries[i][len(entries[i])-1] == '/' {
+			hdr.Typeflag, hdr.Mode, hdr.Size = tar.TypeDir, 0o755, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entries[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "manifests.tar.gz")
+	writeTarGz(t, archive,
+		"manifests/", "",
+		"manifests/pod.yaml", "apiVersion: v1\nkind: Pod\nmetadata:\n  name: a\n",
+		"manifests/README.md", "not yaml: [",
+		"manifests/svc.yml", "apiVersion: v1\nkind: Service\nmetadata:\n  name: s\nspec:\n  ports:\n    - port: 80\n",
+	)
+	res := (&Validator{}).ValidatePaths(archive)
+	var files []string
+	for _, r := range res.Files {
+		if r.Archive != archive {
+			t.Errorf("%s: Archive is %q, want %q", r.File, r.Archive, archive)
+		}
+		files = append(files, r.File)
+	}
+	if want := []string{"manifests/pod.yaml", "manifests/svc.yml"}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("got entries %q, want %q", files, want)
+	}
+	if c := res.Files[0].Count; c.Errors == 0 {
+		t.Error("pod without containers passed")
+	}
+	if c := res.Files[1].Count; c.Errors != 0 {
+		t.Errorf("service: %v", res.Files[1].Errors)
+	}
+}
+
+func TestValidateArchiveCorrupt(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "broken.tgz")
+	if err := os.WriteFile(archive, []byte("not gzip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res := (&Validator{}).ValidatePaths(archive)
+	if len(res.Files) != 1 || len(res.Files[0].Errors) != 1 || res.Files[0].Errors[0].Rule != "DOC002" {
+		t.Fatalf("got %+v, want one DOC002 finding", res.Files)
+	}
+}
@@ -0,0 +1,222 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the project config file looked up by FindConfig.
+const ConfigFileName = ".yamlvalid.yaml"
+
+// Config holds the settings of a project config file:
+//
+//	rules:
+//	  DOC012: off              # severity only
+//	  POD016:
+//	    severity: warning
+//	    maxCPU: 32
+//	    maxMemory: 64Gi
+//	  POD028:
+//	    allowedRegistries: [registry.example.com, "*.corp.example.com"]
+//
+// Rules without Go code go in a customRules section; see ExprRule.
+// Options left out of the file are nil or empty, so the caller can tell
+// them apart from explicit values.
+type Config struct {
+	Severities map[string]string
+
+	MaxCPU             *int
+	MaxMemory          *int64
+	AllowedNamespaces  []string
+	RequireNamespace   *bool
+	NameTransform      string
+	AllowedRegistries  []string
+	ImageTagPolicy     string
+	ContainerNameStyle string
+	QuantityUnits      []string
+
+	// CustomRules are the rules of the customRules section; see ExprRule.
+	// They still need to be registered with RegisterRule.
+	CustomRules []*ExprRule
+}
+
+// ruleOptions lists the options each rule accepts besides severity.
+var ruleOptions = map[string][]string{
+	"DOC007": {"transform"},
+	"DOC009": {"allowedNamespaces", "requireNamespace"},
+	"POD016": {"maxCPU", "maxMemory"},
+	"POD028": {"allowedRegistries"},
+	"POD029": {"policy"},
+	"POD030": {"style"},
+	"POD031": {"units"},
+}
+
+// FindConfig returns the path of the first ConfigFileName found in dir
+// or one of its parents, or "" if there is none.
+func FindConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ConfigFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig reads a project config file. Like LoadRuleConfig it returns
+// every problem found, each prefixed with the file and line.
+func LoadConfig(path string) (*Config, []string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("Error reading config: %v", err)}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, []string{parseErrorText(path, "config", err)}
+	}
+	cfg := &Config{Severities: make(map[string]string)}
+	if root.Kind == 0 {
+		// Empty file
+		return cfg, nil
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, []string{fmt.Sprintf("%s:%d config must be object", path, doc.Line)}
+	}
+
+	var errs []string
+	for i := 0; i < len(doc.Content); i += 2 {
+		if k := doc.Content[i]; k.Value != "rules" && k.Value != "customRules" {
+			errs = append(errs, fmt.Sprintf("%s:%d unknown config key '%s'", path, k.Line, k.Value))
+		}
+	}
+	// Custom rules come first so the rules section can configure them
+	custom := make(map[string]bool)
+	if node := findMapKey(doc, "customRules"); node != nil {
+		var ruleErrs []string
+		cfg.CustomRules, ruleErrs = parseExprRules(path, node)
+		errs = append(errs, ruleErrs...)
+		for _, r := range cfg.CustomRules {
+			custom[r.ID] = true
+		}
+	}
+	rulesNode := findMapKey(doc, "rules")
+	if rulesNode == nil {
+		return cfg, errs
+	}
+	if rulesNode.Kind != yaml.MappingNode {
+		return nil, append(errs, fmt.Sprintf("%s:%d rules must be object", path, rulesNode.Line))
+	}
+	for i := 0; i < len(rulesNode.Content); i += 2 {
+		k, v := rulesNode.Content[i], rulesNode.Content[i+1]
+		if FindRule(k.Value) == nil && !custom[k.Value] {
+			errs = append(errs, fmt.Sprintf("%s:%d unknown rule id '%s'", path, k.Line, k.Value))
+			continue
+		}
+		switch v.Kind {
+		case yaml.ScalarNode:
+			errs = append(errs, cfg.setSeverity(path, k.Value, v)...)
+		case yaml.MappingNode:
+			for j := 0; j < len(v.Content); j += 2 {
+				opt, val := v.Content[j], v.Content[j+1]
+				if opt.Value == "severity" {
+					errs = append(errs, cfg.setSeverity(path, k.Value, val)...)
+				} else if !contains(ruleOptions[k.Value], opt.Value) {
+					errs = append(errs, fmt.Sprintf("%s:%d rule %s has no option '%s'", path, opt.Line, k.Value, opt.Value))
+				} else if err := cfg.setOption(opt.Value, val); err != "" {
+					errs = append(errs, fmt.Sprintf("%s:%d option %s %s", path, val.Line, opt.Value, err))
+				}
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("%s:%d rule %s must be a severity or object", path, v.Line, k.Value))
+		}
+	}
+	return cfg, errs
+}
+
+func (c *Config) setSeverity(path, id string, node *yaml.Node) []string {
+	if node.Kind != yaml.ScalarNode || !validSeverity(node.Value) {
+		return []string{fmt.Sprintf("%s:%d rule %s has unsupported severity '%s'", path, node.Line, id, node.Value)}
+	}
+	c.Severities[id] = node.Value
+	return nil
+}
+
+// parseErrorText describes a YAML syntax error in the config file path,
+// led by file:line like the other config errors when the parser gives
+// the line.
+func parseErrorText(path, what string, err error) string {
+	if line := yamlErrorLine(err); line > 0 {
+		return fmt.Sprintf("%s:%d Error parsing %s: %v", path, line, what, err)
+	}
+	return fmt.Sprintf("%s: Error parsing %s: %v", path, what, err)
+}
+
+// setOption stores one rule option, returning what is wrong with the
+// value, if anything.
+func (c *Config) setOption(name string, node *yaml.Node) string {
+	switch name {
+	case "maxCPU":
+		var n int
+		if node.Decode(&n) != nil {
+			return "must be int"
+		}
+		c.MaxCPU = &n
+	case "maxMemory":
+		n, ok := ParseMemory(node.Value)
+		if node.Kind != yaml.ScalarNode || !ok {
+			return "must be a memory quantity"
+		}
+		c.MaxMemory = &n
+	case "allowedNamespaces":
+		if node.Decode(&c.AllowedNamespaces) != nil {
+			return "must be a list of strings"
+		}
+	case "requireNamespace":
+		var b bool
+		if node.Decode(&b) != nil {
+			return "must be bool"
+		}
+		c.RequireNamespace = &b
+	case "allowedRegistries":
+		if node.Decode(&c.AllowedRegistries) != nil {
+			return "must be a list of strings"
+		}
+		for _, pattern := range c.AllowedRegistries {
+			if !ValidRegistryPattern(pattern) {
+				return fmt.Sprintf("has invalid registry pattern '%s'", pattern)
+			}
+		}
+	case "units":
+		if node.Decode(&c.QuantityUnits) != nil {
+			return "must be a list of strings"
+		}
+	case "policy":
+		if node.Kind != yaml.ScalarNode || !ValidTagPolicy(node.Value) {
+			return fmt.Sprintf("has unsupported value '%s'", node.Value)
+		}
+		c.ImageTagPolicy = node.Value
+	case "style":
+		if node.Kind != yaml.ScalarNode || !ValidNameStyle(node.Value) {
+			return fmt.Sprintf("has unsupported value '%s'", node.Value)
+		}
+		c.ContainerNameStyle = node.Value
+	case "transform":
+		if node.Kind != yaml.ScalarNode || !ValidNameTransform(node.Value) {
+			return fmt.Sprintf("has unsupported value '%s'", node.Value)
+		}
+		c.NameTransform = node.Value
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes src to a config file in a temporary directory and
+// returns its path.
+func writeConfig(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"valid", "rules:\n  POD028:\n    allowedRegistries: [registry.example.com, \"*.corp.example.com:5000/team\"]\n", nil},
+		{"registry with scheme", "rules:\n  POD028:\n    allowedRegistries: [\"https://registry.example.com\"]\n",
+			[]string{":3 option allowedRegistries has invalid registry pattern 'https://registry.example.com'"}},
+		{"wildcard inside a registry", "rules:\n  POD028:\n    allowedRegistries: [\"reg*.example.com\"]\n",
+			[]string{":3 option allowedRegistries has invalid registry pattern 'reg*.example.com'"}},
+		{"unknown key", "rule:\n  DOC012: off\n", []string{":1 unknown config key 'rule'"}},
+		{"unknown rule id", "rules:\n  DOC012: off\n  POD999: warning\n", []string{":3 unknown rule id 'POD999'"}},
+		{"unsupported severity", "rules:\n  DOC012: loud\n", []string{":2 rule DOC012 has unsupported severity 'loud'"}},
+		{"unknown option", "rules:\n  POD016:\n    maxGPU: 1\n", []string{":3 rule POD016 has no option 'maxGPU'"}},
+		{"option of wrong type", "rules:\n  POD016:\n    maxCPU: lots\n", []string{":3 option maxCPU must be int"}},
+		{"rules not object", "rules: [DOC012]\n", []string{":1 rules must be object"}},
+		{"every problem reported", "extra: 1\nrules:\n  NOPE: off\n  DOC012: loud\n",
+			[]string{":1 unknown config key 'extra'", ":3 unknown rule id 'NOPE'", ":4 rule DOC012 has unsupported severity 'loud'"}},
+		{"syntax error", "rules:\n  DOC012: off\n   POD016: warning\n",
+			[]string{":3 Error parsing config: yaml: line 3:"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeConfig(t, tc.src)
+			_, errs := LoadConfig(path)
+			if len(errs) != len(tc.want) {
+				t.Fatalf("got errors %q, want %d", errs, len(tc.want))
+			}
+			for i, want := range tc.want {
+				if !strings.HasPrefix(errs[i], path+want) {
+					t.Errorf("got %q, want it to start with %q", errs[i], path+want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, "rules:\n  DOC012: off\n  POD016:\n    severity: warning\n    maxCPU: 32\n    maxMemory: 64Gi\n  POD030:\n    style: snake_case\n")
+	cfg, errs := LoadConfig(path)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %q", errs)
+	}
+	if cfg.Severities["DOC012"] != SeverityOff || cfg.Severities["POD016"] != SeverityWarning {
+		t.Errorf("got severities %v", cfg.Severities)
+	}
+	if cfg.MaxCPU == nil || *cfg.MaxCPU != 32 {
+		t.Errorf("got maxCPU %v, want 32", cfg.MaxCPU)
+	}
+	if cfg.MaxMemory == nil || *cfg.MaxMemory != 64<<30 {
+		t.Errorf("got maxMemory %v, want 64Gi", cfg.MaxMemory)
+	}
+	if cfg.ContainerNameStyle != NameStyleSnakeCase {
+		t.Errorf("got container name style %q", cfg.ContainerNameStyle)
+	}
+	// Options left out stay unset
+	if cfg.AllowedRegistries != nil || cfg.RequireNamespace != nil {
+		t.Errorf("got options that were not set: %+v", cfg)
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "project")
+	nested := filepath.Join(project, "deploy", "base")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(project, ConfigFileName)
+	if err := os.WriteFile(config, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name, dir, want string
+	}{
+		{"in the directory", project, config},
+		{"in a parent", nested, config},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := FindConfig(tc.dir); got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+	// Above the project nothing is found, at least not in the test tree
+	if got := FindConfig(root); strings.HasPrefix(got, root) {
+		t.Fatalf("got %q outside the project", got)
+	}
+}
@@ -0,0 +1,137 @@
+package validator
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var configKeyRe = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+var secretTypes = []string{
+	"Opaque",
+	"kubernetes.io/service-account-token",
+	"kubernetes.io/dockercfg",
+	"kubernetes.io/dockerconfigjson",
+	"kubernetes.io/basic-auth",
+	"kubernetes.io/ssh-auth",
+	"kubernetes.io/tls",
+	"bootstrap.kubernetes.io/token",
+}
+
+// configMapRules are reported by the ConfigMap and Secret checks.
+var configMapRules = []Rule{
+	{
+		ID: "CM001", Severity: SeverityError,
+		Summary:     "configMap data key has invalid format",
+		Description: "ConfigMap data and binaryData must be objects whose keys consist of alphanumerics, '-', '_' and '.'.",
+		Example:     "data:\n  app config: x",
+		Fix:         "Rename the key, e.g. app-config.",
+	},
+	{
+		ID: "CM002", Severity: SeverityError,
+		Summary:     "configMap value must be string (base64 in binaryData)",
+		Description: "ConfigMap values are strings; binaryData values are base64-encoded bytes.",
+		Example:     "binaryData:\n  logo.png: not-base64!",
+		Fix:         "Quote the value, or base64-encode binaryData content.",
+	},
+	{
+		ID: "DOC003", Severity: SeverityError,
+		Summary:     "spec is not valid for this kind",
+		Description: "ConfigMap and Secret have no spec; a spec block is usually a paste from a Pod or Deployment.",
+		Example:     "kind: ConfigMap\nspec:\n  containers: []",
+		Fix:         "Remove the spec or fix the kind.",
+	},
+	{
+		ID: "SEC001", Severity: SeverityError,
+		Summary:     "secret type has unsupported value",
+		Description: "Types under the kubernetes.io/ namespace are reserved; only the built-in ones exist.",
+		Example:     "type: kubernetes.io/password",
+		Fix:         "Use Opaque or one of the built-in secret types.",
+	},
+	{
+		ID: "SEC002", Severity: SeverityError,
+		Summary:     "secret data key has invalid format",
+		Description: "Secret data and stringData must be objects whose keys consist of alphanumerics, '-', '_' and '.'.",
+		Example:     "data:\n  db password: cGFzcw==",
+		Fix:         "Rename the key, e.g. db-password.",
+	},
+	{
+		ID: "SEC003", Severity: SeverityError,
+		Summary:     "secret data value must be base64",
+		Description: "Secret data values are base64-encoded; plain text belongs in stringData.",
+		Example:     "data:\n  password: hunter2",
+		Fix:         "Base64-encode the value or move it to stringData.",
+	},
+}
+
+func validateConfigMap(mapping *yaml.Node, filename string) []ValidationError {
+	errs := noSpec(mapping, "ConfigMap", filename)
+	errs = append(errs, validateDataMap(mapping, "data", "configMap", false, filename)...)
+	errs = append(errs, validateDataMap(mapping, "binaryData", "configMap", true, filename)...)
+	return errs
+}
+
+func validateSecret(mapping *yaml.Node, filename string) []ValidationError {
+	errs := noSpec(mapping, "Secret", filename)
+	if typeNode := findMapKey(mapping, "type"); typeNode != nil {
+		if !isString(typeNode) {
+			errs = append(errs, newFieldError(filename, typeNode, "type", "SEC001", "type must be string").withQuoteFix(typeNode))
+		} else if isBuiltinSecretType(typeNode.Value) && !contains(secretTypes, typeNode.Value) {
+			errs = append(errs, newFieldError(filename, typeNode, "type", "SEC001", "type has unsupported value '%s'", typeNode.Value))
+		}
+	}
+	errs = append(errs, validateDataMap(mapping, "data", "secret", true, filename)...)
+	errs = append(errs, validateDataMap(mapping, "stringData", "secret", false, filename)...)
+	return errs
+}
+
+// noSpec reports a spec on kinds that carry their payload at the top
+// level; it is most likely pasted from another manifest.
+func noSpec(mapping *yaml.Node, kind, filename string) []ValidationError {
+	if keyNode := findMapKeyNode(mapping, "spec"); keyNode != nil {
+		return []ValidationError{newFieldError(filename, keyNode, "spec", "DOC003", "spec is not valid for kind %s", kind)}
+	}
+	return nil
+}
+
+// isBuiltinSecretType reports whether a type lives in a namespace reserved
+// by Kubernetes; custom types elsewhere are allowed.
+func isBuiltinSecretType(t string) bool {
+	return t == "Opaque" || strings.HasPrefix(t, "kubernetes.io/") || strings.HasPrefix(t, "bootstrap.kubernetes.io/")
+}
+
+// validateDataMap checks a map of configuration keys to string values,
+// optionally requiring the values to be base64 encoded.
+func validateDataMap(mapping *yaml.Node, field, kind string, base64Values bool, filename string) []ValidationError {
+	var errs []ValidationError
+	dataNode := findMapKey(mapping, field)
+	if dataNode == nil {
+		return nil
+	}
+	keyRule, valueRule := "CM001", "CM002"
+	if kind == "secret" {
+		keyRule, valueRule = "SEC002", "SEC003"
+	}
+	if dataNode.Kind != yaml.MappingNode {
+		return []ValidationError{newFieldError(filename, dataNode, field, keyRule, "%s must be object", field)}
+	}
+	for i := 0; i < len(dataNode.Content); i += 2 {
+		k, val := dataNode.Content[i], dataNode.Content[i+1]
+		if !configKeyRe.MatchString(k.Value) {
+			errs = append(errs, newFieldError(filename, k, field, keyRule, "%s %s key has invalid format '%s'", kind, field, k.Value))
+		}
+		if !isString(val) {
+			errs = append(errs, newFieldError(filename, val, field, valueRule, "%s %s value for '%s' must be string", kind, field, k.Value).withQuoteFix(val))
+			continue
+		}
+		if base64Values {
+			if _, err := base64.StdEncoding.DecodeString(val.Value); err != nil {
+				errs = append(errs, newFieldError(filename, val, field, valueRule, "%s %s value for '%s' must be base64", kind, field, k.Value))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,141 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// customResourceDefinition holds the parts of a CustomResourceDefinition
+// needed to validate its resources. apiextensions.k8s.io/v1 keeps a
+// schema per version; v1beta1 may share one in spec.validation.
+type customResourceDefinition struct {
+	Metadata struct {
+		Name string
+	}
+	Spec struct {
+		Group string
+		Names struct {
+			Kind string
+		}
+		Version    string
+		Validation struct {
+			OpenAPIV3Schema *jsonSchema `yaml:"openAPIV3Schema"`
+		}
+		Versions []struct {
+			Name   string
+			Schema struct {
+				OpenAPIV3Schema *jsonSchema `yaml:"openAPIV3Schema"`
+			}
+		}
+	}
+}
+
+// LoadCRDs adds the openAPIV3Schema of every CustomResourceDefinition in
+// the YAML files below dir, so custom resources are checked like the
+// kinds of LoadDir. Other documents in those files are ignored, as are
+// versions without a schema.
+func (set *SchemaSet) LoadCRDs(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isYAMLFile(path) {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := set.addCRDs(f); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+// addCRDs registers the CustomResourceDefinitions among the documents
+// read from r.
+func (set *SchemaSet) addCRDs(r io.Reader) error {
+	dec := yaml.NewDecoder(r)
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mapping := doc.Content[0]
+		if mapping.Kind != yaml.MappingNode || scalarValue(mapping, "kind") != "CustomResourceDefinition" {
+			continue
+		}
+		var crd customResourceDefinition
+		if err := mapping.Decode(&crd); err != nil {
+			return fmt.Errorf("line %d: %v", mapping.Line, err)
+		}
+		if err := set.addCRD(&crd); err != nil {
+			return fmt.Errorf("line %d: CustomResourceDefinition %s: %v", mapping.Line, crd.Metadata.Name, err)
+		}
+	}
+}
+
+func (set *SchemaSet) addCRD(crd *customResourceDefinition) error {
+	spec := &crd.Spec
+	if spec.Group == "" || spec.Names.Kind == "" {
+		return fmt.Errorf("spec.group and spec.names.kind are required")
+	}
+	shared := spec.Validation.OpenAPIV3Schema
+	if len(spec.Versions) == 0 && spec.Version != "" && shared != nil {
+		return set.addCRDVersion(GroupVersionKind{spec.Group, spec.Version, spec.Names.Kind}, shared)
+	}
+	for _, version := range spec.Versions {
+		s := version.Schema.OpenAPIV3Schema
+		if s == nil {
+			s = shared
+		}
+		if s == nil {
+			continue
+		}
+		if err := set.addCRDVersion(GroupVersionKind{spec.Group, version.Name, spec.Names.Kind}, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addCRDVersion registers the schema of one version. CRD schemas may
+// leave out apiVersion, kind and metadata, which the API server adds to
+// every custom resource, so they are added here too; metadata gets the
+// checks of every document instead.
+func (set *SchemaSet) addCRDVersion(gvk GroupVersionKind, s *jsonSchema) error {
+	if err := resolveRefs(s, "", nil, make(map[*jsonSchema]bool)); err != nil {
+		return err
+	}
+	if s.Properties != nil {
+		for _, field := range []string{"apiVersion", "kind"} {
+			if _, ok := s.Properties[field]; !ok {
+				s.Properties[field] = &jsonSchema{Type: typeList{"string"}}
+			}
+		}
+		if _, ok := s.Properties["metadata"]; !ok {
+			s.Properties["metadata"] = &jsonSchema{Type: typeList{"object"}}
+		}
+	}
+	set.register(gvk, s)
+	return nil
+}
@@ -0,0 +1,83 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCRDs(t *testing.T) {
+	var set SchemaSet
+	if err := set.LoadCRDs(filepath.Join("testdata", "crds")); err != nil {
+		t.Fatal(err)
+	}
+	// Strict mode reports fields the schema does not list, so a clean
+	// run shows apiVersion, kind and metadata were added to it
+	v := &Validator{Schemas: &set, Strict: true}
+	for _, tc := range []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "v1 valid",
+			src:  "apiVersion: example.com/v1\nkind: Backup\nmetadata:\n  name: nightly\n  labels:\n    app: db\nspec:\n  schedule: \"0 1 * * *\"\n  keep: 7\n",
+		},
+		{
+			name: "v1 schema",
+			src:  "apiVersion: example.com/v1\nkind: Backup\nmetadata:\n  name: nightly\nspec:\n  keep: 0\n",
+			want: []string{"6:9 keep value 0 is below the minimum 1", "6:3 schedule is required"},
+		},
+		{
+			name: "v1alpha1 schema",
+			src:  "apiVersion: example.com/v1alpha1\nkind: Backup\nmetadata:\n  name: nightly\nspec:\n  keep: 7\n",
+			want: []string{"6:3 unknown field 'keep'"},
+		},
+		{
+			name: "v1beta1 shared schema",
+			src:  "apiVersion: example.com/v1beta1\nkind: Restore\nmetadata:\n  name: r\nspec:\n  from: hourly\n",
+			want: []string{"6:9 from has unsupported value 'hourly', allowed: daily, weekly"},
+		},
+		{
+			name: "v1beta1 shared schema other version",
+			src:  "apiVersion: example.com/v1\nkind: Restore\nmetadata:\n  name: r\nspec:\n  from: hourly\n",
+			want: []string{"6:9 from has unsupported value 'hourly', allowed: daily, weekly"},
+		},
+		{
+			name: "v1beta1 single version",
+			src:  "apiVersion: example.com/v1\nkind: Snapshot\nmetadata:\n  name: s\nspec:\n  size: big\n",
+			want: []string{"6:9 size must be int"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, e := range v.ValidateBytes([]byte(tc.src), "test.yaml") {
+				if e.Rule == "DOC017" || e.Rule == "DOC010" {
+					got = append(got, strings.TrimPrefix(e.Location(), "test.yaml:")+e.Message)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+	if set.lookup(GroupVersionKind{Version: "v1", Kind: "ConfigMap"}) != nil {
+		t.Error("documents other than CustomResourceDefinitions were loaded")
+	}
+}
+
+func TestCRDErrors(t *testing.T) {
+	dir := t.TempDir()
+	src := "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: broken.example.com\nspec:\n  names:\n    kind: Broken\n"
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var set SchemaSet
+	err := set.LoadCRDs(dir)
+	want := "broken.yaml: line 1: CustomResourceDefinition broken.example.com: spec.group and spec.names.kind are required"
+	if err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Fatalf("got error %v, want %q", err, want)
+	}
+}
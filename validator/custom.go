@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Finding is a problem a custom rule found in a document. Node is where
+// it is reported, the document itself when nil; Field names the
+// offending field, if any.
+type Finding struct {
+	Node    *yaml.Node
+	Field   string
+	Message string
+}
+
+// RuleFunc checks one document for a custom rule. doc is the mapping at
+// the root of the document, of any kind; it must not be modified.
+type RuleFunc func(doc *yaml.Node) []Finding
+
+type customRule struct {
+	id    string
+	check RuleFunc
+}
+
+// customRules run on every document, in registration order.
+var customRules []customRule
+
+// RegisterRule adds an organization-specific rule without changing the
+// validator. r describes it like a built-in rule: findings are reported
+// under r.ID with r.Severity, OptIn is honored, and the rule config and
+// --explain-rule work on it. A team keeps its rules in a package of its
+// own whose init function registers them, and links it in with a blank
+// import in main.go.
+//
+// RegisterRule panics if r.ID is empty or taken or r.Severity is not
+// error, warning or info, since that is a programming error. It is not
+// safe to call while validation is running.
+func RegisterRule(r Rule, check RuleFunc) {
+	switch {
+	case r.ID == "":
+		panic("validator: RegisterRule with empty rule id")
+	case FindRule(r.ID) != nil:
+		panic(fmt.Sprintf("validator: rule %s registered twice", r.ID))
+	case !validSeverity(r.Severity) || r.Severity == SeverityOff:
+		panic(fmt.Sprintf("validator: rule %s has invalid severity '%s'", r.ID, r.Severity))
+	}
+	rulesByID[r.ID] = &r
+	customRules = append(customRules, customRule{id: r.ID, check: check})
+}
+
+// validateCustomRules runs the registered rules on the document mapping.
+func validateCustomRules(mapping *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	for _, c := range customRules {
+		for _, f := range c.check(mapping) {
+			node := f.Node
+			if node == nil {
+				node = mapping
+			}
+			errs = append(errs, newFieldError(filename, node, f.Field, c.id, "%s", f.Message))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,47 @@
+package validator
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+// dirTasks lists every YAML file below dir in lexical order, skipping
+// hidden directories and files already in seen, for validation.
+// Unreadable directories are reported right away.
+func dirTasks(dir string, seen map[string]bool) []*fileTask {
+	var tasks []*fileTask
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			tasks = append(tasks, doneTask(FileResult{File: path, Errors: []ValidationError{{File: path, Rule: "DOC002", Message: fmt.Sprintf("Error reading directory: %v", err)}}}))
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isYAMLFile(path) && !seen[pathKey(path)] {
+			seen[pathKey(path)] = true
+			tasks = append(tasks, &fileTask{path: path, done: make(chan struct{})})
+		}
+		return nil
+	})
+	return tasks
+}
+
+// pathKey identifies a file regardless of how its path was spelled.
+func pathKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+)
+
+var envVarRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// envRules are reported by --substitute-env.
+var envRules = []Rule{
+	{
+		ID: "DOC001", Severity: SeverityError,
+		Summary:     "undefined variable in --substitute-env mode",
+		Description: "A ${VAR} placeholder has no value in the environment, so the manifest would be applied with the literal placeholder.",
+		Example:     "image: ${IMAGE}   # IMAGE is not exported",
+		Fix:         "Export the variable before running the validator or remove the placeholder.",
+	},
+}
+
+// substituteEnv expands ${VAR} placeholders from the process environment,
+// the way envsubst would at deploy time. Unset variables are left as-is
+// and reported.
+func substituteEnv(data []byte, filename string) ([]byte, []ValidationError) {
+	var errs []ValidationError
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		var out []byte
+		last := 0
+		for _, m := range envVarRe.FindAllSubmatchIndex(line, -1) {
+			out = append(out, line[last:m[0]]...)
+			last = m[1]
+			name := string(line[m[2]:m[3]])
+			val, ok := os.LookupEnv(name)
+			if !ok {
+				errs = append(errs, ValidationError{File: filename, Line: i + 1, Column: m[0] + 1, Rule: "DOC001", Message: "undefined variable " + name})
+				out = append(out, line[m[0]:m[1]]...)
+				continue
+			}
+			out = append(out, val...)
+		}
+		if last > 0 {
+			lines[i] = append(out, line[last:]...)
+		}
+	}
+	return bytes.Join(lines, []byte("\n")), errs
+}
@@ -0,0 +1,298 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExprRule is a custom rule declared in the customRules section of the
+// project config, for policies that need no Go code:
+//
+//	customRules:
+//	  - id: ORG001
+//	    severity: warning
+//	    summary: containers must set a memory limit
+//	    kinds: [Pod]
+//	    select: spec.containers[*]
+//	    field: resources.limits.memory
+//	    present: true
+//	    message: container '{name}' must set resources.limits.memory
+//
+// Select picks the nodes to check, the document when empty, and field
+// is the path below each of them the conditions apply to, the node itself
+// when empty. The conditions are present, true if field must be set and
+// false if it must not be, pattern, a regexp the whole value must match,
+// like the patterns of schemas.yaml, and oneOf, the values it may take;
+// pattern and oneOf only apply when the field is set. In message, {path}
+// stands for the value at that path below the selected node. Register it
+// with RegisterRule(r.Rule, r.Check).
+type ExprRule struct {
+	Rule
+
+	kinds      []string
+	selectPath []pathStep
+	fieldPath  []pathStep
+	// field is the field path as written, for findings
+	field   string
+	present *bool
+	pattern *regexp.Regexp
+	oneOf   []string
+	message string
+}
+
+// pathStep is one step of a path: a mapping key, an index into a
+// sequence, or every item of a sequence (index -1).
+type pathStep struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// parsePath parses a path such as spec.containers[*].ports[0]. Wildcards
+// are only allowed when wildcard is set.
+func parsePath(path string, wildcard bool) ([]pathStep, error) {
+	if path == "" {
+		return nil, nil
+	}
+	var steps []pathStep
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key == "" && (len(steps) > 0 || rest == "") {
+			return nil, fmt.Errorf("empty key in path '%s'", path)
+		}
+		if key != "" {
+			steps = append(steps, pathStep{key: key, isKey: true})
+		}
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("missing ']' in path '%s'", path)
+			}
+			switch n, err := strconv.Atoi(idx); {
+			case idx == "*":
+				if !wildcard {
+					return nil, fmt.Errorf("[*] is not allowed in '%s', use select", path)
+				}
+				steps = append(steps, pathStep{index: -1})
+			case err == nil && n >= 0:
+				steps = append(steps, pathStep{index: n})
+			default:
+				return nil, fmt.Errorf("invalid index '%s' in path '%s'", idx, path)
+			}
+			if after != "" && !strings.HasPrefix(after, "[") {
+				return nil, fmt.Errorf("unexpected '%s' in path '%s'", after, path)
+			}
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return steps, nil
+}
+
+// walkPath returns the nodes path leads to from node.
+func walkPath(node *yaml.Node, path []pathStep) []*yaml.Node {
+	nodes := []*yaml.Node{node}
+	for _, step := range path {
+		var next []*yaml.Node
+		for _, n := range nodes {
+			switch {
+			case step.isKey:
+				if v := findMapKey(n, step.key); v != nil {
+					next = append(next, v)
+				}
+			case step.index < 0:
+				next = append(next, sequenceItems(n)...)
+			case step.index < len(sequenceItems(n)):
+				next = append(next, n.Content[step.index])
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// Check runs the rule on one document; it is the RuleFunc of the rule.
+func (r *ExprRule) Check(doc *yaml.Node) []Finding {
+	if len(r.kinds) > 0 && !contains(r.kinds, scalarValue(doc, "kind")) {
+		return nil
+	}
+	var findings []Finding
+	for _, sel := range walkPath(doc, r.selectPath) {
+		var value *yaml.Node
+		if values := walkPath(sel, r.fieldPath); len(values) > 0 {
+			value = values[0]
+		}
+		var msg string
+		node := value
+		switch {
+		case r.present != nil && *r.present && value == nil:
+			msg, node = fmt.Sprintf("%s is required", r.field), sel
+		case r.present != nil && !*r.present && value != nil:
+			msg = fmt.Sprintf("%s must not be set", r.field)
+		case value == nil:
+			continue
+		case r.pattern != nil && (value.Kind != yaml.ScalarNode || !r.pattern.MatchString(value.Value)):
+			msg = fmt.Sprintf("%s has invalid format '%s'", r.field, value.Value)
+		case r.oneOf != nil && (value.Kind != yaml.ScalarNode || !contains(r.oneOf, value.Value)):
+			msg = fmt.Sprintf("%s has unsupported value '%s', allowed: %s", r.field, value.Value, strings.Join(r.oneOf, ", "))
+		default:
+			continue
+		}
+		if r.message != "" {
+			msg = expandMessage(r.message, sel)
+		}
+		findings = append(findings, Finding{Node: node, Field: r.field, Message: msg})
+	}
+	return findings
+}
+
+var placeholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// expandMessage replaces each {path} in msg with the scalar at that path
+// below node, or nothing if there is none.
+func expandMessage(msg string, node *yaml.Node) string {
+	return placeholder.ReplaceAllStringFunc(msg, func(m string) string {
+		path, err := parsePath(m[1:len(m)-1], false)
+		if err != nil {
+			return m
+		}
+		if values := walkPath(node, path); len(values) > 0 && values[0].Kind == yaml.ScalarNode {
+			return values[0].Value
+		}
+		return ""
+	})
+}
+
+// exprRuleKeys are the keys of a customRules entry.
+var exprRuleKeys = []string{
+	"id", "severity", "optIn", "summary", "description", "example", "fix",
+	"kinds", "select", "field", "present", "pattern", "oneOf", "message",
+}
+
+// parseExprRules reads the customRules section of the config at path,
+// returning every problem found like LoadConfig does.
+func parseExprRules(path string, node *yaml.Node) ([]*ExprRule, []string) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, []string{fmt.Sprintf("%s:%d customRules must be array", path, node.Line)}
+	}
+	var rules []*ExprRule
+	var errs []string
+	ids := make(map[string]bool)
+	for _, entry := range node.Content {
+		r, entryErrs := parseExprRule(path, entry)
+		errs = append(errs, entryErrs...)
+		if r == nil {
+			continue
+		}
+		if FindRule(r.ID) != nil || ids[r.ID] {
+			errs = append(errs, fmt.Sprintf("%s:%d rule id '%s' is already taken", path, entry.Line, r.ID))
+			continue
+		}
+		ids[r.ID] = true
+		rules = append(rules, r)
+	}
+	return rules, errs
+}
+
+func parseExprRule(path string, entry *yaml.Node) (*ExprRule, []string) {
+	if entry.Kind != yaml.MappingNode {
+		return nil, []string{fmt.Sprintf("%s:%d customRules entry must be object", path, entry.Line)}
+	}
+	var errs []string
+	fail := func(node *yaml.Node, format string, args ...any) {
+		errs = append(errs, fmt.Sprintf("%s:%d ", path, node.Line)+fmt.Sprintf(format, args...))
+	}
+	for i := 0; i+1 < len(entry.Content); i += 2 {
+		if k := entry.Content[i]; !contains(exprRuleKeys, k.Value) {
+			fail(k, "custom rule has no key '%s'", k.Value)
+		}
+	}
+	r := &ExprRule{Rule: Rule{Severity: SeverityError}}
+	strField := func(key string, dst *string) {
+		if n := findMapKey(entry, key); n != nil {
+			if !isString(n) {
+				fail(n, "custom rule %s must be string", key)
+				return
+			}
+			*dst = n.Value
+		}
+	}
+	strField("id", &r.ID)
+	strField("severity", &r.Severity)
+	strField("summary", &r.Summary)
+	strField("description", &r.Description)
+	strField("example", &r.Example)
+	strField("fix", &r.Fix)
+	strField("message", &r.message)
+	if r.ID == "" {
+		fail(entry, "custom rule id is required")
+	}
+	if !validSeverity(r.Severity) || r.Severity == SeverityOff {
+		fail(findMapKey(entry, "severity"), "custom rule has unsupported severity '%s'", r.Severity)
+	}
+	if n := findMapKey(entry, "optIn"); n != nil && n.Decode(&r.OptIn) != nil {
+		fail(n, "custom rule optIn must be bool")
+	}
+	if n := findMapKey(entry, "kinds"); n != nil && n.Decode(&r.kinds) != nil {
+		fail(n, "custom rule kinds must be a list of strings")
+	}
+
+	var sel string
+	strField("select", &sel)
+	strField("field", &r.field)
+	var err error
+	if r.selectPath, err = parsePath(sel, true); err != nil {
+		fail(findMapKey(entry, "select"), "custom rule select: %v", err)
+	}
+	if r.fieldPath, err = parsePath(r.field, false); err != nil {
+		fail(findMapKey(entry, "field"), "custom rule field: %v", err)
+	}
+	if r.field == "" {
+		// Name the selected field, e.g. image for spec.containers[*].image
+		for _, step := range r.selectPath {
+			if step.isKey {
+				r.field = step.key
+			}
+		}
+	}
+	if r.field == "" {
+		fail(entry, "custom rule needs select or field")
+	}
+
+	conditions := 0
+	if n := findMapKey(entry, "present"); n != nil {
+		conditions++
+		var b bool
+		if n.Decode(&b) != nil {
+			fail(n, "custom rule present must be bool")
+		}
+		r.present = &b
+	}
+	if n := findMapKey(entry, "pattern"); n != nil {
+		conditions++
+		if re, err := regexp.Compile("^(?:" + n.Value + ")$"); n.Kind != yaml.ScalarNode || err != nil {
+			fail(n, "custom rule pattern is not a valid regexp")
+		} else {
+			r.pattern = re
+		}
+	}
+	if n := findMapKey(entry, "oneOf"); n != nil {
+		conditions++
+		if n.Decode(&r.oneOf) != nil {
+			fail(n, "custom rule oneOf must be a list of strings")
+		}
+	}
+	if conditions == 0 {
+		fail(entry, "custom rule needs one of present, pattern or oneOf")
+	}
+	if r.Summary == "" {
+		r.Summary = r.field + " check from the project config"
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return r, nil
+}
@@ -0,0 +1,105 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseRules parses src as the customRules section of config.yaml.
+func parseRules(t *testing.T, src string) ([]*ExprRule, []string) {
+	t.Helper()
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &root); err != nil {
+		t.Fatal(err)
+	}
+	return parseExprRules("config.yaml", root.Content[0])
+}
+
+// checkRule runs the single rule of src on the document doc and returns
+// the messages of its findings.
+func checkRule(t *testing.T, src, doc string) []string {
+	t.Helper()
+	rules, errs := parseRules(t, src)
+	if len(errs) > 0 || len(rules) != 1 {
+		t.Fatalf("got rules %v and errors %q, want one rule", rules, errs)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+		t.Fatal(err)
+	}
+	var msgs []string
+	for _, f := range rules[0].Check(root.Content[0]) {
+		msgs = append(msgs, f.Message)
+	}
+	return msgs
+}
+
+const exprPod = `kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+    - name: app
+      image: nginx:1.25
+      resources: {limits: {memory: 1Gi}}
+    - name: sidecar
+      image: busybox
+`
+
+func TestExprRuleCheck(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rule string
+		want []string
+	}{
+		{"present", "- {id: T1, select: \"spec.containers[*]\", field: resources.limits.memory, present: true}",
+			[]string{"resources.limits.memory is required"}},
+		{"absent", "- {id: T1, field: spec.hostNetwork, present: false}", nil},
+		{"must not be set", "- {id: T1, select: \"spec.containers[*]\", field: resources, present: false}",
+			[]string{"resources must not be set"}},
+		{"pattern matches the whole value", "- {id: T1, select: \"spec.containers[*].image\", pattern: '[a-z]+'}",
+			[]string{"image has invalid format 'nginx:1.25'"}},
+		{"pattern", "- {id: T1, select: \"spec.containers[*].image\", pattern: '[a-z]+(:[0-9.]+)?'}",
+			nil},
+		{"oneOf", "- {id: T1, select: \"spec.containers[*].name\", oneOf: [app]}",
+			[]string{"name has unsupported value 'sidecar', allowed: app"}},
+		{"index", "- {id: T1, select: \"spec.containers[1].name\", oneOf: [app]}",
+			[]string{"name has unsupported value 'sidecar', allowed: app"}},
+		{"other kinds", "- {id: T1, kinds: [Deployment], field: spec.replicas, present: true}", nil},
+		{"message", "- {id: T1, select: \"spec.containers[*]\", field: resources, present: true, message: \"container '{name}' ({image}) needs resources, {missing.key}\"}",
+			[]string{"container 'sidecar' (busybox) needs resources, "}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := checkRule(t, tc.rule, exprPod)
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExprRuleErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"unknown key", "- {id: T1, field: a, present: true, matches: x}", []string{"config.yaml:1 custom rule has no key 'matches'"}},
+		{"no condition", "- {id: T1, field: a}", []string{"config.yaml:1 custom rule needs one of present, pattern or oneOf"}},
+		{"bad regexp", "- {id: T1, field: a, pattern: '(['}", []string{"config.yaml:1 custom rule pattern is not a valid regexp"}},
+		{"wildcard in field", "- {id: T1, field: \"a[*]\", present: true}", []string{"config.yaml:1 custom rule field: [*] is not allowed in 'a[*]', use select"}},
+		{"bad index", "- {id: T1, select: \"a[x]\", field: b, present: true}", []string{"config.yaml:1 custom rule select: invalid index 'x' in path 'a[x]'"}},
+		{"built-in id", "- {id: POD001, field: a, present: true}", []string{"config.yaml:1 rule id 'POD001' is already taken"}},
+		{"id twice", "- {id: T1, field: a, present: true}\n- {id: T1, field: b, present: true}", []string{"config.yaml:2 rule id 'T1' is already taken"}},
+		{"no id", "- {field: a, present: true}", []string{"config.yaml:1 custom rule id is required"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, errs := parseRules(t, tc.src)
+			if strings.Join(errs, "\n") != strings.Join(tc.want, "\n") {
+				t.Fatalf("got %q, want %q", errs, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package validator
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fix is a mechanical repair of a finding: the source text from Line
+// and Column up to EndLine and EndColumn, exclusive, is replaced with
+// Replacement, which is YAML source text, e.g. "8080" with the quotes or
+// TCP. Lines and columns are 1-based and count characters like those of
+// findings.
+type Fix struct {
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	EndLine     int    `json:"endLine"`
+	EndColumn   int    `json:"endColumn"`
+	Replacement string `json:"replacement"`
+}
+
+// withFix attaches a fix replacing the node of the finding with
+// replacement. Its range is filled in by setFixRanges.
+func (e ValidationError) withFix(replacement string) ValidationError {
+	e.Fix = &Fix{Replacement: replacement}
+	return e
+}
+
+// withQuoteFix attaches a fix quoting node, for a plain scalar such as
+// 8080 or true where a string is expected.
+func (e ValidationError) withQuoteFix(node *yaml.Node) ValidationError {
+	if node.Kind != yaml.ScalarNode || node.Style != 0 || node.Tag == "!!null" {
+		return e
+	}
+	return e.withFix(strconv.Quote(node.Value))
+}
+
+// withValueFix attaches a fix setting node to value, written in the
+// quoting style of node. Block scalars are left alone.
+func (e ValidationError) withValueFix(node *yaml.Node, value string) ValidationError {
+	switch node.Style {
+	case 0:
+		return e.withFix(value)
+	case yaml.DoubleQuotedStyle:
+		return e.withFix(strconv.Quote(value))
+	case yaml.SingleQuotedStyle:
+		return e.withFix("'" + strings.ReplaceAll(value, "'", "''") + "'")
+	}
+	return e
+}
+
+// setFixRanges sets the range of each fix to the source text of the node
+// its finding points at, found in source. Fixes whose node is not a
+// single-line scalar there, or all of them when source is nil, are
+// dropped.
+func setFixRanges(source []byte, errs []ValidationError) {
+	var lines [][]byte
+	for i := range errs {
+		f, n := errs[i].Fix, errs[i].node
+		if f == nil {
+			continue
+		}
+		errs[i].Fix = nil
+		if source == nil || n == nil {
+			continue
+		}
+		if lines == nil {
+			lines = bytes.SplitAfter(source, []byte("\n"))
+		}
+		if n.Line < 1 || n.Line > len(lines) {
+			continue
+		}
+		line := lines[n.Line-1]
+		start := columnOffset(line, n.Column)
+		if start < 0 {
+			continue
+		}
+		end := scalarEnd(line[start:], n)
+		if end < 0 {
+			continue
+		}
+		f.Line, f.Column = n.Line, n.Column
+		f.EndLine, f.EndColumn = n.Line, n.Column+utf8.RuneCount(line[start:start+end])
+		errs[i].Fix = f
+	}
+}
+
+// FixData validates data and applies the fixes of its findings to the
+// source text, so comments and formatting are kept byte for byte. It
+// returns the fixed data and the findings that were fixed, in file order.
+// Findings that need judgment have no fix and are left for the author.
+// SubstituteEnv must be off, since it leaves no fixes.
+func (v *Validator) FixData(data []byte, filename string) ([]byte, []ValidationError) {
+	var fixable []ValidationError
+	for _, e := range v.ValidateBytes(data, filename) {
+		if e.Fix != nil {
+			fixable = append(fixable, e)
+		}
+	}
+	// Later positions first, so the offsets of earlier ones stay valid
+	sort.SliceStable(fixable, func(i, j int) bool {
+		a, b := fixable[i].Fix, fixable[j].Fix
+		if a.Line != b.Line {
+			return a.Line > b.Line
+		}
+		return a.Column > b.Column
+	})
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	var fixed []ValidationError
+	var last *Fix
+	for _, e := range fixable {
+		f := e.Fix
+		// A node is fixed once, whatever rules report it
+		if last != nil && last.Line == f.Line && last.Column == f.Column {
+			continue
+		}
+		line := lines[f.Line-1]
+		start, end := columnOffset(line, f.Column), columnOffset(line, f.EndColumn)
+		lines[f.Line-1] = append(append(append([]byte{}, line[:start]...), f.Replacement...), line[end:]...)
+		fixed = append(fixed, e)
+		last = f
+	}
+	for i, j := 0, len(fixed)-1; i < j; i, j = i+1, j-1 {
+		fixed[i], fixed[j] = fixed[j], fixed[i]
+	}
+	return bytes.Join(lines, nil), fixed
+}
+
+// columnOffset returns the byte offset of the 1-based column col, which
+// counts characters, or -1 if the line is shorter.
+func columnOffset(line []byte, col int) int {
+	off := 0
+	for i := 1; i < col; i++ {
+		if off >= len(line) {
+			return -1
+		}
+		_, size := utf8.DecodeRune(line[off:])
+		off += size
+	}
+	return off
+}
+
+// scalarEnd returns the length of the source text of the single-line
+// scalar n at the start of src, or -1 if src does not hold it.
+func scalarEnd(src []byte, n *yaml.Node) int {
+	switch n.Style {
+	case 0:
+		if bytes.HasPrefix(src, []byte(n.Value)) {
+			return len(n.Value)
+		}
+	case yaml.DoubleQuotedStyle:
+		for i := 1; i < len(src); i++ {
+			switch src[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+	case yaml.SingleQuotedStyle:
+		for i := 1; i < len(src); i++ {
+			if src[i] == '\'' {
+				if i+1 < len(src) && src[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,39 @@
+package validator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFixData(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "fix", "input.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "fix", "want.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &Validator{}
+	fixed, findings := v.FixData(input, "input.yaml")
+	if !bytes.Equal(fixed, want) {
+		t.Errorf("fixed data differs from want.yaml:\n%s", fixed)
+	}
+	var rules []string
+	for _, e := range findings {
+		rules = append(rules, e.Rule)
+	}
+	wantRules := []string{"DOC013", "POD013", "POD043", "POD034", "CM002", "CM002", "SVC003", "SVC002"}
+	if !reflect.DeepEqual(rules, wantRules) {
+		t.Errorf("fixed %v, want %v", rules, wantRules)
+	}
+
+	// Fixing the fixed data again changes nothing
+	again, findings := v.FixData(fixed, "input.yaml")
+	if !bytes.Equal(again, fixed) || len(findings) != 0 {
+		t.Errorf("second run fixed %d findings:\n%s", len(findings), again)
+	}
+}
@@ -0,0 +1,200 @@
+package validator
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	registryHostRe  = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]{1,5})?$`)
+	repoComponentRe = regexp.MustCompile(`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*$`)
+	imageTagRe      = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+	imageDigestRe   = regexp.MustCompile(`^[a-z0-9]+([+._-][a-z0-9]+)*:[a-fA-F0-9]{32,}$`)
+)
+
+// imageRef is an image reference split into its parts. Registry, Tag and
+// Digest are empty when the reference does not carry them.
+type imageRef struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// parseImageRef splits registry/repository:tag@digest. The tag separator
+// is the last ':' after the last '/', so a registry port such as
+// host:5000/app:1.2 is not mistaken for a tag.
+func parseImageRef(image string) imageRef {
+	var ref imageRef
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+	if host, ok := registryHost(name); ok {
+		ref.Registry = host
+		name = name[len(host)+1:]
+	}
+	ref.Repository = name
+	return ref
+}
+
+// imageRules are reported by the image checks below.
+var imageRules = []Rule{
+	{
+		ID: "POD019", Severity: SeverityError,
+		Summary:     "image has invalid format",
+		Description: "The image is required and must be a string of the form [registry/]repository[:tag][@digest]. The registry must be a valid hostname with an optional port, repository path components lowercase, and the tag at most 128 characters.",
+		Example:     "image: ://bad/app:1",
+		Fix:         "Use a reference like registry.example.com:5000/team/app:1.2.",
+	},
+	{
+		ID: "POD028", Severity: SeverityError,
+		Summary:     "image registry is not allowed",
+		Description: "With --allowed-registries (or the allowedRegistries option), images must come from one of the listed registries. Entries are hosts, \"*.\" wildcards for subdomains, or host/path prefixes. Images without a registry host come from docker.io.",
+		Example:     "image: nginx:1.25   # allowed: registry.example.com",
+		Fix:         "Pull the image through an allowed registry or mirror.",
+	},
+	{
+		ID: "POD029", Severity: SeverityError,
+		Summary:     "image violates the tag policy",
+		Description: "--image-tag-policy (or the policy option) decides how images must be pinned: any accepts everything, no-latest rejects :latest and untagged images, digest-only requires an @sha256: digest. Mutable tags make rollouts and rollbacks unpredictable.",
+		Example:     "image: nginx:latest",
+		Fix:         "Pin a version tag, or the digest: nginx@sha256:<digest>.",
+	},
+}
+
+func validateImage(contNode *yaml.Node, filename string) []ValidationError {
+	imageNode, reqErrs := requiredScalar(contNode, "image", "image", "POD019", filename)
+	if imageNode == nil {
+		return reqErrs
+	}
+	if imageNode.Kind != yaml.ScalarNode {
+		return []ValidationError{newFieldError(filename, imageNode, "image", "POD019", "image must be string")}
+	}
+	ref := parseImageRef(imageNode.Value)
+	if ref.Registry != "" && !registryHostRe.MatchString(ref.Registry) {
+		return []ValidationError{newFieldError(filename, imageNode, "image", "POD019", "image has invalid registry host '%s'", ref.Registry)}
+	}
+	for _, comp := range strings.Split(ref.Repository, "/") {
+		if !repoComponentRe.MatchString(comp) {
+			return []ValidationError{newFieldError(filename, imageNode, "image", "POD019", "image has invalid repository '%s'", ref.Repository)}
+		}
+	}
+	if ref.Tag != "" && !imageTagRe.MatchString(ref.Tag) {
+		return []ValidationError{newFieldError(filename, imageNode, "image", "POD019", "image has invalid tag '%s'", ref.Tag)}
+	}
+	if ref.Digest != "" && !imageDigestRe.MatchString(ref.Digest) {
+		return []ValidationError{newFieldError(filename, imageNode, "image", "POD019", "image has invalid digest '%s'", ref.Digest)}
+	}
+	return nil
+}
+
+// registryHost returns the registry part of an image reference. Like the
+// container runtimes, the first path component only counts as a host when
+// it looks like one (has a dot or port, or is localhost).
+func registryHost(image string) (string, bool) {
+	i := strings.Index(image, "/")
+	if i < 0 {
+		return "", false
+	}
+	host := image[:i]
+	if strings.ContainsAny(host, ".:") || host == "localhost" {
+		return host, true
+	}
+	return "", false
+}
+
+// defaultRegistry is where runtimes pull images without a registry host.
+const defaultRegistry = "docker.io"
+
+// validateRegistry reports images pulled from a registry outside
+// AllowedRegistries. A pattern is a host (with port, if the registry
+// uses one), a host with a leading "*." for any of its subdomains, or a
+// host and path prefix such as ghcr.io/team.
+func (v *Validator) validateRegistry(contNode *yaml.Node, filename string) []ValidationError {
+	imageNode := findMapKey(contNode, "image")
+	if imageNode == nil || imageNode.Kind != yaml.ScalarNode || imageNode.Value == "" {
+		return nil
+	}
+	ref := parseImageRef(imageNode.Value)
+	if ref.Registry == "" {
+		ref.Registry = defaultRegistry
+	} else if !registryHostRe.MatchString(ref.Registry) {
+		// already reported by validateImage
+		return nil
+	}
+	for _, pattern := range v.AllowedRegistries {
+		if registryAllowed(pattern, ref) {
+			return nil
+		}
+	}
+	return []ValidationError{newFieldError(filename, imageNode, "image", "POD028", "image registry '%s' is not allowed", ref.Registry)}
+}
+
+// ValidRegistryPattern reports whether pattern is usable in
+// AllowedRegistries: an optional leading "*.", a host with an optional
+// port, and an optional repository path prefix.
+func ValidRegistryPattern(pattern string) bool {
+	host, prefix, hasPrefix := strings.Cut(strings.TrimPrefix(pattern, "*."), "/")
+	if !registryHostRe.MatchString(host) {
+		return false
+	}
+	if hasPrefix {
+		for _, comp := range strings.Split(prefix, "/") {
+			if !repoComponentRe.MatchString(comp) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func registryAllowed(pattern string, ref imageRef) bool {
+	host, prefix, _ := strings.Cut(pattern, "/")
+	if strings.HasPrefix(host, "*.") {
+		if !strings.HasSuffix(ref.Registry, host[1:]) {
+			return false
+		}
+	} else if host != ref.Registry {
+		return false
+	}
+	return prefix == "" || ref.Repository == prefix || strings.HasPrefix(ref.Repository, path.Clean(prefix)+"/")
+}
+
+// Image tag policies for Validator.ImageTagPolicy.
+const (
+	TagPolicyAny        = "any"
+	TagPolicyNoLatest   = "no-latest"
+	TagPolicyDigestOnly = "digest-only"
+)
+
+func ValidTagPolicy(p string) bool {
+	return p == TagPolicyAny || p == TagPolicyNoLatest || p == TagPolicyDigestOnly
+}
+
+// validateTagPolicy enforces ImageTagPolicy. An image with neither tag
+// nor digest counts as latest, since that is what the runtime pulls.
+func (v *Validator) validateTagPolicy(contNode *yaml.Node, filename string) []ValidationError {
+	imageNode := findMapKey(contNode, "image")
+	if imageNode == nil || imageNode.Kind != yaml.ScalarNode || imageNode.Value == "" {
+		return nil
+	}
+	ref := parseImageRef(imageNode.Value)
+	switch v.ImageTagPolicy {
+	case TagPolicyNoLatest:
+		if ref.Digest == "" && (ref.Tag == "" || ref.Tag == "latest") {
+			return []ValidationError{newFieldError(filename, imageNode, "image", "POD029", "image uses the latest tag")}
+		}
+	case TagPolicyDigestOnly:
+		if ref.Digest == "" {
+			return []ValidationError{newFieldError(filename, imageNode, "image", "POD029", "image is not pinned by digest")}
+		}
+	}
+	return nil
+}
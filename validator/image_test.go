@@ -0,0 +1,26 @@
+package validator
+
+import "testing"
+
+func TestParseImageRef(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	for _, tc := range []struct {
+		image string
+		want  imageRef
+	}{
+		{"host:5000/repo:tag", imageRef{Registry: "host:5000", Repository: "repo", Tag: "tag"}},
+		{"host:5000/repo", imageRef{Registry: "host:5000", Repository: "repo"}},
+		{"repo@" + digest, imageRef{Repository: "repo", Digest: digest}},
+		{"host:5000/repo:tag@" + digest, imageRef{Registry: "host:5000", Repository: "repo", Tag: "tag", Digest: digest}},
+		{"docker.io/library/nginx", imageRef{Registry: "docker.io", Repository: "library/nginx"}},
+		{"registry.example.com:5000/team/app:1.2", imageRef{Registry: "registry.example.com:5000", Repository: "team/app", Tag: "1.2"}},
+		{"localhost/app", imageRef{Registry: "localhost", Repository: "app"}},
+		{"team/app:1.2", imageRef{Repository: "team/app", Tag: "1.2"}},
+	} {
+		t.Run(tc.image, func(t *testing.T) {
+			if got := parseImageRef(tc.image); got != tc.want {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
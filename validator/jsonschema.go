@@ -0,0 +1,598 @@
+package validator
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaSet holds external JSON Schema or OpenAPI definitions, keyed by
+// the kind they describe. Documents are checked against them in addition
+// to the built-in rules (DOC017), which also covers kinds the validator
+// has no rules for. The zero value is an empty set.
+type SchemaSet struct {
+	kinds map[GroupVersionKind]*jsonSchema
+}
+
+// jsonSchema is the subset of JSON Schema used by Kubernetes OpenAPI
+// definitions and CRDs, plus the Kubernetes extensions that change how a
+// value is checked. Other keywords are ignored.
+type jsonSchema struct {
+	Ref                  string                 `yaml:"$ref"`
+	Type                 typeList               `yaml:"type"`
+	Format               string                 `yaml:"format"`
+	Properties           map[string]*jsonSchema `yaml:"properties"`
+	AdditionalProperties *additionalProperties  `yaml:"additionalProperties"`
+	Items                *jsonSchema            `yaml:"items"`
+	Required             []string               `yaml:"required"`
+	Enum                 []yaml.Node            `yaml:"enum"`
+	Pattern              string                 `yaml:"pattern"`
+	Minimum              *float64               `yaml:"minimum"`
+	Maximum              *float64               `yaml:"maximum"`
+	MinLength            *int                   `yaml:"minLength"`
+	MaxLength            *int                   `yaml:"maxLength"`
+	MinItems             *int                   `yaml:"minItems"`
+	MaxItems             *int                   `yaml:"maxItems"`
+	AllOf                []*jsonSchema          `yaml:"allOf"`
+	AnyOf                []*jsonSchema          `yaml:"anyOf"`
+	OneOf                []*jsonSchema          `yaml:"oneOf"`
+	IntOrString          bool                   `yaml:"x-kubernetes-int-or-string"`
+	PreserveUnknown      bool                   `yaml:"x-kubernetes-preserve-unknown-fields"`
+	GroupVersionKinds    []GroupVersionKind     `yaml:"x-kubernetes-group-version-kind"`
+
+	// Named schemas of an OpenAPI v2, OpenAPI v3 or JSON Schema document
+	Definitions map[string]*jsonSchema `yaml:"definitions"`
+	Defs        map[string]*jsonSchema `yaml:"$defs"`
+	Components  struct {
+		Schemas map[string]*jsonSchema `yaml:"schemas"`
+	} `yaml:"components"`
+
+	ref     *jsonSchema
+	pattern *regexp.Regexp
+	// quantity accepts any scalar, see markQuantities
+	quantity bool
+}
+
+// typeList is the type keyword, a single type or a list of them.
+type typeList []string
+
+func (t *typeList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = typeList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// additionalProperties is either false, forbidding fields not listed in
+// properties, or a schema for their values.
+type additionalProperties struct {
+	Forbidden bool
+	Schema    *jsonSchema
+}
+
+func (a *additionalProperties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!bool" {
+		a.Forbidden = node.Value == "false"
+		return nil
+	}
+	a.Schema = new(jsonSchema)
+	return node.Decode(a.Schema)
+}
+
+// definitions returns the named schemas of a document, whichever of the
+// supported layouts it uses.
+func (s *jsonSchema) definitions() map[string]*jsonSchema {
+	defs := make(map[string]*jsonSchema, len(s.Definitions)+len(s.Defs)+len(s.Components.Schemas))
+	for _, set := range []map[string]*jsonSchema{s.Definitions, s.Defs, s.Components.Schemas} {
+		for name, d := range set {
+			defs[name] = d
+		}
+	}
+	return defs
+}
+
+// isSchema reports whether a document describes a value itself rather
+// than only holding definitions.
+func (s *jsonSchema) isSchema() bool {
+	return len(s.Type) > 0 || s.Properties != nil || s.Ref != "" || s.AllOf != nil || s.AnyOf != nil || s.OneOf != nil
+}
+
+// LoadDir adds the schemas of every .json, .yaml and .yml file directly
+// in dir. A file may be an OpenAPI document such as a cluster's
+// /openapi/v2, whose definitions name their kinds in
+// x-kubernetes-group-version-kind, or the schema of a single kind. A
+// single-kind schema without that extension is matched by its file name:
+// kind-version.json for the core group, kind-group-version.json
+// otherwise, where group may also be just the first label of the group
+// as some schema generators write it. $ref may point into another file
+// of the same directory. Later schemas for a kind replace earlier ones.
+func (set *SchemaSet) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	docs := make(map[string]*jsonSchema)
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		doc, err := parseSchemaDocument(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", filepath.Join(dir, e.Name()), err)
+		}
+		docs[e.Name()] = doc
+	}
+	for _, e := range entries {
+		if doc, ok := docs[e.Name()]; ok {
+			if err := set.add(doc, e.Name(), docs); err != nil {
+				return fmt.Errorf("%s: %v", filepath.Join(dir, e.Name()), err)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadURL adds the definitions of the OpenAPI or JSON Schema document at
+// url, typically the /openapi/v2 endpoint of a cluster or a published
+// copy of it. Only references within the document are followed.
+func (set *SchemaSet) LoadURL(url string) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %v", url, err)
+	}
+	doc, err := parseSchemaDocument(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", url, err)
+	}
+	name := path.Base(url)
+	if err := set.add(doc, name, map[string]*jsonSchema{name: doc}); err != nil {
+		return fmt.Errorf("%s: %v", url, err)
+	}
+	return nil
+}
+
+// parseSchemaDocument decodes a JSON or YAML schema document; JSON is
+// read as YAML.
+func parseSchemaDocument(data []byte) (*jsonSchema, error) {
+	var doc jsonSchema
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// add resolves the references of doc, read from the file name, and
+// registers the kinds it describes.
+func (set *SchemaSet) add(doc *jsonSchema, name string, docs map[string]*jsonSchema) error {
+	markQuantities(doc)
+	if err := resolveRefs(doc, name, docs, make(map[*jsonSchema]bool)); err != nil {
+		return err
+	}
+	for _, d := range doc.definitions() {
+		for _, gvk := range d.GroupVersionKinds {
+			set.register(gvk, d)
+		}
+	}
+	if !doc.isSchema() {
+		return nil
+	}
+	gvks := doc.GroupVersionKinds
+	if len(gvks) == 0 {
+		gvk, ok := kindFromFilename(name)
+		if !ok {
+			return fmt.Errorf("cannot tell which kind the schema describes; name the file kind-version.json or kind-group-version.json")
+		}
+		gvks = []GroupVersionKind{gvk}
+	}
+	for _, gvk := range gvks {
+		set.register(gvk, doc)
+	}
+	return nil
+}
+
+// register makes s the schema of gvk, replacing any earlier one.
+func (set *SchemaSet) register(gvk GroupVersionKind, s *jsonSchema) {
+	if set.kinds == nil {
+		set.kinds = make(map[GroupVersionKind]*jsonSchema)
+	}
+	set.kinds[schemaKey(gvk)] = s
+}
+
+// markQuantities lets resource quantities be numbers as well as strings.
+// OpenAPI declares them as strings, but the API server accepts cpu: 1
+// just as it accepts cpu: "1".
+func markQuantities(doc *jsonSchema) {
+	for name, d := range doc.definitions() {
+		if strings.HasSuffix(name, "pkg.api.resource.Quantity") {
+			d.quantity = true
+		}
+	}
+}
+
+// kindFromFilename derives the kind of a single-kind schema from a file
+// name like pod-v1.json or deployment-apps-v1.json.
+func kindFromFilename(name string) (GroupVersionKind, bool) {
+	parts := strings.Split(strings.TrimSuffix(name, filepath.Ext(name)), "-")
+	if len(parts) < 2 || parts[0] == "" || parts[len(parts)-1] == "" {
+		return GroupVersionKind{}, false
+	}
+	return GroupVersionKind{
+		Group:   strings.Join(parts[1:len(parts)-1], "-"),
+		Version: parts[len(parts)-1],
+		Kind:    parts[0],
+	}, true
+}
+
+// schemaKey normalizes a kind for lookups. Kinds are matched regardless
+// of case, since file names carry them in lowercase.
+func schemaKey(gvk GroupVersionKind) GroupVersionKind {
+	return GroupVersionKind{Group: strings.ToLower(gvk.Group), Version: gvk.Version, Kind: strings.ToLower(gvk.Kind)}
+}
+
+// resolveRefs links every $ref below s to its target and compiles the
+// patterns. Patterns Go's regexp cannot compile, such as lookaheads, are
+// not checked.
+func resolveRefs(s *jsonSchema, file string, docs map[string]*jsonSchema, seen map[*jsonSchema]bool) error {
+	if s == nil || seen[s] {
+		return nil
+	}
+	seen[s] = true
+	if s.Ref != "" {
+		target, err := lookupRef(s.Ref, file, docs)
+		if err != nil {
+			return err
+		}
+		s.ref = target
+		// The target may live in another file, with references of its own
+		refFile := file
+		if i := strings.Index(s.Ref, "#"); i > 0 {
+			refFile = path.Base(s.Ref[:i])
+		}
+		if err := resolveRefs(target, refFile, docs, seen); err != nil {
+			return err
+		}
+		// A chain of references leading back to s never reaches a schema
+		// to check against
+		chain := map[*jsonSchema]bool{s: true}
+		for t := target; t != nil; t = t.ref {
+			if chain[t] {
+				return fmt.Errorf("circular $ref %q", s.Ref)
+			}
+			chain[t] = true
+		}
+	}
+	if s.Pattern != "" {
+		s.pattern, _ = regexp.Compile(s.Pattern)
+	}
+	var children []*jsonSchema
+	for _, p := range s.Properties {
+		children = append(children, p)
+	}
+	for _, d := range s.definitions() {
+		children = append(children, d)
+	}
+	if s.AdditionalProperties != nil {
+		children = append(children, s.AdditionalProperties.Schema)
+	}
+	children = append(children, s.Items)
+	children = append(children, s.AllOf...)
+	children = append(children, s.AnyOf...)
+	children = append(children, s.OneOf...)
+	for _, c := range children {
+		if err := resolveRefs(c, file, docs, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupRef finds the schema a $ref such as #/definitions/io.k8s.api.core.v1.Pod
+// or _definitions.json#/definitions/... points to.
+func lookupRef(ref, file string, docs map[string]*jsonSchema) (*jsonSchema, error) {
+	target, pointer, _ := strings.Cut(ref, "#")
+	if target == "" {
+		target = file
+	}
+	doc, ok := docs[path.Base(target)]
+	if !ok {
+		return nil, fmt.Errorf("unresolved $ref %q", ref)
+	}
+	if pointer == "" || pointer == "/" {
+		return doc, nil
+	}
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	var defs map[string]*jsonSchema
+	var name string
+	switch {
+	case strings.HasPrefix(pointer, "/definitions/"):
+		defs, name = doc.Definitions, strings.TrimPrefix(pointer, "/definitions/")
+	case strings.HasPrefix(pointer, "/$defs/"):
+		defs, name = doc.Defs, strings.TrimPrefix(pointer, "/$defs/")
+	case strings.HasPrefix(pointer, "/components/schemas/"):
+		defs, name = doc.Components.Schemas, strings.TrimPrefix(pointer, "/components/schemas/")
+	}
+	if s, ok := defs[unescape.Replace(name)]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unresolved $ref %q", ref)
+}
+
+// lookup finds the schema for gvk. Like lookupKind, a document without
+// apiVersion is matched on kind alone if only one version is known.
+func (set *SchemaSet) lookup(gvk GroupVersionKind) *jsonSchema {
+	if set == nil || gvk.Kind == "" {
+		return nil
+	}
+	key := schemaKey(gvk)
+	if s, ok := set.kinds[key]; ok {
+		return s
+	}
+	if key.Group != "" || key.Version != "" {
+		// Schema generators often name files after the first label of
+		// the group only
+		if i := strings.Index(key.Group, "."); i > 0 {
+			key.Group = key.Group[:i]
+			return set.kinds[key]
+		}
+		return nil
+	}
+	var found *jsonSchema
+	for k, s := range set.kinds {
+		if k.Kind == key.Kind {
+			if found != nil {
+				return nil
+			}
+			found = s
+		}
+	}
+	return found
+}
+
+// jsonSchemaTypes maps JSON Schema types to how they are named in
+// findings, matching the built-in schemas.
+var jsonSchemaTypes = map[string]string{
+	"integer": "int",
+	"number":  "number",
+	"boolean": "bool",
+	"string":  "string",
+	"object":  "object",
+	"array":   "array",
+	"null":    "null",
+}
+
+// jsonSchemaRules are reported by checkJSONSchema.
+var jsonSchemaRules = []Rule{
+	{
+		ID: "DOC017", Severity: SeverityError,
+		Summary:     "document does not match an external schema",
+		Description: "With --schema-dir or --schema-url, documents whose kind has a JSON Schema or OpenAPI definition are checked against it, and with --crd-dir custom resources against the openAPIV3Schema of their CustomResourceDefinition, in addition to the built-in rules: types, required fields, enums, patterns, lengths, ranges and item counts, following $ref, allOf, anyOf and oneOf. Fields the schema does not list are reported when it sets additionalProperties to false, and otherwise as DOC010 with --strict.",
+		Example:     "apiVersion: apps/v1\nkind: Deployment\nspec:\n  replicas: two",
+		Fix:         "Change the document to match the schema, or update the schema if it is out of date.",
+	},
+}
+
+// checkJSONSchema checks node, the value of field, against an external
+// schema (DOC017). Fields the schema does not list are reported as DOC010
+// in strict mode, or always when additionalProperties is false.
+func (v *Validator) checkJSONSchema(node *yaml.Node, s *jsonSchema, field, filename string) []ValidationError {
+	for s != nil && s.ref != nil {
+		s = s.ref
+	}
+	if node == nil || s == nil {
+		return nil
+	}
+	// Like the API server, treat null as leaving the field out
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	var errs []ValidationError
+	for _, sub := range s.AllOf {
+		errs = append(errs, v.checkJSONSchema(node, sub, field, filename)...)
+	}
+	if s.AnyOf != nil && v.matchCount(node, s.AnyOf, field, filename) == 0 {
+		errs = append(errs, newFieldError(filename, node, field, "DOC017", "%s does not match any of the allowed schemas", field))
+	}
+	if s.OneOf != nil {
+		switch v.matchCount(node, s.OneOf, field, filename) {
+		case 0:
+			errs = append(errs, newFieldError(filename, node, field, "DOC017", "%s does not match any of the allowed schemas", field))
+		case 1:
+		default:
+			errs = append(errs, newFieldError(filename, node, field, "DOC017", "%s matches more than one of the allowed schemas", field))
+		}
+	}
+	if !s.hasType(node) {
+		return append(errs, newFieldError(filename, node, field, "DOC017", "%s must be %s", field, s.typeName()))
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		errs = append(errs, s.checkScalar(node, field, filename)...)
+	case yaml.SequenceNode:
+		if s.MinItems != nil && len(node.Content) < *s.MinItems {
+			errs = append(errs, newFieldError(filename, node, field, "DOC017", "%s must have at least %d items", field, *s.MinItems))
+		}
+		if s.MaxItems != nil && len(node.Content) > *s.MaxItems {
+			errs = append(errs, newFieldError(filename, node, field, "DOC017", "%s must have at most %d items", field, *s.MaxItems))
+		}
+		for _, item := range node.Content {
+			errs = append(errs, v.checkJSONSchema(item, s.Items, field, filename)...)
+		}
+	case yaml.MappingNode:
+		errs = append(errs, v.checkProperties(node, s, filename)...)
+	}
+	return errs
+}
+
+// matchCount returns how many of the schemas node satisfies.
+func (v *Validator) matchCount(node *yaml.Node, schemas []*jsonSchema, field, filename string) int {
+	n := 0
+	for _, sub := range schemas {
+		if len(v.checkJSONSchema(node, sub, field, filename)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// hasType reports whether node has one of the types s allows.
+func (s *jsonSchema) hasType(node *yaml.Node) bool {
+	if s.quantity && node.Kind == yaml.ScalarNode {
+		return true
+	}
+	if s.IntOrString || s.Format == "int-or-string" {
+		return node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!str")
+	}
+	if len(s.Type) == 0 {
+		return true
+	}
+	for _, t := range s.Type {
+		switch t {
+		case "object":
+			if node.Kind == yaml.MappingNode {
+				return true
+			}
+		case "array":
+			if node.Kind == yaml.SequenceNode {
+				return true
+			}
+		case "string":
+			// Unquoted timestamps are still strings in JSON
+			if node.Kind == yaml.ScalarNode && (node.Tag == "!!str" || node.Tag == "!!timestamp" || node.Tag == "!!binary") {
+				return true
+			}
+		case "integer":
+			if node.Kind == yaml.ScalarNode && node.Tag == "!!int" {
+				return true
+			}
+		case "number":
+			if node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!float") {
+				return true
+			}
+		case "boolean":
+			if node.Kind == yaml.ScalarNode && node.Tag == "!!bool" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeName describes the types s allows, for findings.
+func (s *jsonSchema) typeName() string {
+	if s.IntOrString || s.Format == "int-or-string" {
+		return "int or string"
+	}
+	names := make([]string, len(s.Type))
+	for i, t := range s.Type {
+		names[i] = jsonSchemaTypes[t]
+	}
+	return strings.Join(names, " or ")
+}
+
+// checkScalar checks the enum, pattern, length and range constraints of
+// a scalar.
+func (s *jsonSchema) checkScalar(node *yaml.Node, field, filename string) []ValidationError {
+	if s.Enum != nil {
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			allowed[i] = e.Value
+		}
+		if !contains(allowed, node.Value) {
+			return []ValidationError{newFieldError(filename, node, field, "DOC017", "%s has unsupported value '%s', allowed: %s", field, node.Value, strings.Join(allowed, ", "))}
+		}
+	}
+	var errs []ValidationError
+	if node.Tag == "!!str" {
+		if s.pattern != nil && !s.pattern.MatchString(node.Value) {
+			errs = append(errs, newFieldError(filename, node, field, "DOC017", "%s has invalid format '%s', must match '%s'", field, node.Value, s.Pattern))
+		}
+		n := utf8.RuneCountInString(node.Value)
+		if s.MinLength != nil && n < *s.MinLength {
+			errs = append(errs, newFieldError(filename, node, field, "DOC017", "%s is shorter than %d characters", field, *s.MinLength))
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			errs = append(errs, newFieldError(filename, node, field, "DOC017", "%s is longer than %d characters", field, *s.MaxLength))
+		}
+	}
+	if node.Tag == "!!int" || node.Tag == "!!float" {
+		if f, err := strconv.ParseFloat(node.Value, 64); err == nil {
+			if s.Minimum != nil && f < *s.Minimum {
+				errs = append(errs, newFieldError(filename, node, field, "DOC017", "%s value %s is below the minimum %s", field, node.Value, strconv.FormatFloat(*s.Minimum, 'f', -1, 64)))
+			}
+			if s.Maximum != nil && f > *s.Maximum {
+				errs = append(errs, newFieldError(filename, node, field, "DOC017", "%s value %s is above the maximum %s", field, node.Value, strconv.FormatFloat(*s.Maximum, 'f', -1, 64)))
+			}
+		}
+	}
+	return errs
+}
+
+// checkProperties checks the fields of an object against s.Properties,
+// s.Required and s.AdditionalProperties.
+func (v *Validator) checkProperties(node *yaml.Node, s *jsonSchema, filename string) []ValidationError {
+	var errs []ValidationError
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, val := node.Content[i], node.Content[i+1]
+		if sub, ok := s.Properties[k.Value]; ok {
+			errs = append(errs, v.checkJSONSchema(val, sub, k.Value, filename)...)
+			continue
+		}
+		switch {
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+			errs = append(errs, v.checkJSONSchema(val, s.AdditionalProperties.Schema, k.Value, filename)...)
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Forbidden:
+			errs = append(errs, s.unknownField(k, "DOC017", filename))
+		case v.Strict && s.Properties != nil && !s.PreserveUnknown:
+			errs = append(errs, s.unknownField(k, "DOC010", filename))
+		}
+	}
+	for _, name := range s.Required {
+		if findMapKeyNode(node, name) == nil {
+			errs = append(errs, newFieldError(filename, node, name, "DOC017", "%s is required", name))
+		}
+	}
+	return errs
+}
+
+// unknownField reports a field s does not list, suggesting the closest
+// one it does.
+func (s *jsonSchema) unknownField(k *yaml.Node, rule, filename string) ValidationError {
+	known := make([]string, 0, len(s.Properties))
+	for f := range s.Properties {
+		known = append(known, f)
+	}
+	if guess := closestField(k.Value, known); guess != "" {
+		return newFieldError(filename, k, k.Value, rule, "unknown field '%s', did you mean '%s'?", k.Value, guess)
+	}
+	return newFieldError(filename, k, k.Value, rule, "unknown field '%s'", k.Value)
+}
@@ -0,0 +1,104 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	var set SchemaSet
+	if err := set.LoadDir(filepath.Join("testdata", "schemas")); err != nil {
+		t.Fatal(err)
+	}
+	v := &Validator{Schemas: &set}
+	for _, tc := range []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "valid widget",
+			src:  "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\nspec:\n  size: 2\n  color: red\n  parts:\n    - name: bolt\n",
+		},
+		{
+			name: "missing required field",
+			src:  "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n",
+			want: []string{"1:1 spec is required"},
+		},
+		{
+			name: "field through cross-file ref",
+			src:  "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\nspec:\n  color: red\n",
+			want: []string{"6:3 size is required"},
+		},
+		{
+			name: "type",
+			src:  "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\nspec:\n  size: \"2\"\n",
+			want: []string{"6:9 size must be int"},
+		},
+		{
+			name: "enum and minimum",
+			src:  "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\nspec:\n  size: 0\n  color: green\n",
+			want: []string{
+				"6:9 size value 0 is below the minimum 1",
+				"7:10 color has unsupported value 'green', allowed: red, blue",
+			},
+		},
+		{
+			name: "pattern through local ref",
+			src:  "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\nspec:\n  size: 1\n  parts:\n    - name: Bolt\n",
+			want: []string{"8:13 name has invalid format 'Bolt', must match '^[a-z]+$'"},
+		},
+		{
+			name: "additionalProperties false",
+			src:  "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\nspec:\n  size: 1\n  colour: red\n",
+			want: []string{"7:3 unknown field 'colour', did you mean 'color'?"},
+		},
+		{
+			name: "openapi definition",
+			src:  "apiVersion: example.com/v1\nkind: Gadget\nmetadata:\n  name: 3\nspec:\n  replicas: many\n",
+			want: []string{"4:9 name must be string", "6:13 replicas must be int"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, e := range v.ValidateBytes([]byte(tc.src), "test.yaml") {
+				if e.Rule == "DOC017" || e.Rule == "DOC010" {
+					got = append(got, strings.TrimPrefix(e.Location(), "test.yaml:")+e.Message)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaLoadErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		file string
+		src  string
+		want string
+	}{
+		{"self reference", "loop-v1.json", `{"$ref": "#/definitions/A", "definitions": {"A": {"$ref": "#/definitions/A"}}}`, `circular $ref "#/definitions/A"`},
+		{"reference cycle", "loop-v1.json", `{"$ref": "#/definitions/A", "definitions": {"A": {"$ref": "#/definitions/B"}, "B": {"$ref": "#/definitions/A"}}}`, "circular $ref"},
+		{"unresolved reference", "loop-v1.json", `{"$ref": "other.json#/definitions/A"}`, `unresolved $ref "other.json#/definitions/A"`},
+		{"unknown kind", "schema.json", `{"type": "object"}`, "cannot tell which kind"},
+		{"bad document", "bad-v1.json", `{"type": [`, "bad-v1.json"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tc.file), []byte(tc.src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var set SchemaSet
+			err := set.LoadDir(dir)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("got error %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
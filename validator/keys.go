@@ -0,0 +1,44 @@
+package validator
+
+import "gopkg.in/yaml.v3"
+
+// keyRules are reported by validateDuplicateKeys.
+var keyRules = []Rule{
+	{
+		ID: "DOC015", Severity: SeverityError,
+		Summary:     "duplicate key in a mapping",
+		Description: "A key appears twice in the same mapping. The YAML spec forbids it, yet many parsers accept it and silently keep one of the values, so what gets validated may not be what gets applied.",
+		Example:     "containers:\n  - name: web\n    image: nginx:1.25\n    image: nginx:latest",
+		Fix:         "Remove one of the entries.",
+	},
+}
+
+// validateDuplicateKeys reports keys that appear more than once in the
+// same mapping anywhere below root. yaml.v3 keeps both when decoding into
+// nodes, and findMapKey only ever sees the first, so a repeated key would
+// otherwise be validated one way and applied another.
+func validateDuplicateKeys(root *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.MappingNode {
+			first := make(map[string]*yaml.Node)
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k := n.Content[i]
+				if k.Kind != yaml.ScalarNode || k.Value == "<<" {
+					continue
+				}
+				if prev, ok := first[k.Value]; ok {
+					errs = append(errs, newFieldError(filename, k, k.Value, "DOC015", "duplicate key '%s', first defined on line %d", k.Value, prev.Line))
+					continue
+				}
+				first[k.Value] = k
+			}
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	walk(root)
+	return errs
+}
@@ -0,0 +1,87 @@
+package validator
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GroupVersionKind identifies the schema of a document from its
+// apiVersion and kind. Group is empty for the core API group.
+type GroupVersionKind struct {
+	Group   string
+	Version string
+	Kind    string
+}
+
+// String formats the kind as apiVersion followed by kind, e.g.
+// "apps/v1 Deployment".
+func (gvk GroupVersionKind) String() string {
+	apiVersion := gvk.Version
+	if gvk.Group != "" {
+		apiVersion = gvk.Group + "/" + gvk.Version
+	}
+	if apiVersion == "" {
+		return gvk.Kind
+	}
+	return apiVersion + " " + gvk.Kind
+}
+
+// DocumentValidator checks a document of one kind. It runs after the
+// checks that apply to every document, such as those on metadata.
+type DocumentValidator func(v *Validator, mapping *yaml.Node, filename string) []ValidationError
+
+var documentValidators = map[GroupVersionKind]DocumentValidator{
+	{Version: "v1", Kind: "Pod"}:       (*Validator).validatePod,
+	{Version: "v1", Kind: "ConfigMap"}: withSchema(configMapSchema, validateConfigMap),
+	{Version: "v1", Kind: "Secret"}:    withSchema(secretSchema, validateSecret),
+	{Version: "v1", Kind: "Service"}:   withSchema(serviceSchema, validateService),
+}
+
+// RegisterKind routes documents of the given kind to fn, replacing the
+// validator registered for it before, if any. It is not safe to call
+// while validation is running.
+func RegisterKind(gvk GroupVersionKind, fn DocumentValidator) {
+	documentValidators[gvk] = fn
+}
+
+// withSchema adapts a kind validator that needs no settings, checking
+// the document against s before the checks of fn.
+func withSchema(s *schema, fn func(*yaml.Node, string) []ValidationError) DocumentValidator {
+	return func(v *Validator, mapping *yaml.Node, filename string) []ValidationError {
+		errs := v.checkSchema(mapping, s, "", filename)
+		return append(errs, fn(mapping, filename)...)
+	}
+}
+
+func documentKind(mapping *yaml.Node) GroupVersionKind {
+	gvk := GroupVersionKind{Kind: scalarValue(mapping, "kind")}
+	apiVersion := scalarValue(mapping, "apiVersion")
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		gvk.Group, gvk.Version = apiVersion[:i], apiVersion[i+1:]
+	} else {
+		gvk.Version = apiVersion
+	}
+	return gvk
+}
+
+// lookupKind finds the validator for gvk. A document without apiVersion
+// is matched on kind alone, as long as only one version is registered.
+func lookupKind(gvk GroupVersionKind) DocumentValidator {
+	if fn, ok := documentValidators[gvk]; ok || gvk.Kind == "" {
+		return fn
+	}
+	if gvk.Group != "" || gvk.Version != "" {
+		return nil
+	}
+	var found DocumentValidator
+	for key, fn := range documentValidators {
+		if key.Kind == gvk.Kind {
+			if found != nil {
+				return nil
+			}
+			found = fn
+		}
+	}
+	return found
+}
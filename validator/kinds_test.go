@@ -0,0 +1,58 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// BenchmarkKinds validates a valid manifest of each built-in kind, so a
+// slow kind validator shows up on its own.
+func BenchmarkKinds(b *testing.B) {
+	for _, tc := range []struct {
+		kind, file string
+	}{
+		{"Pod", "pod.yaml"},
+		{"ConfigMap", "configmap.yaml"},
+		{"Secret", "secret.yaml"},
+		{"Service", "service.yaml"},
+	} {
+		data, err := os.ReadFile(filepath.Join("testdata", "valid", tc.file))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(tc.kind, func(b *testing.B) {
+			v := &Validator{}
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if errs := v.ValidateBytes(data, tc.file); len(errs) > 0 {
+					b.Fatalf("%s: unexpected finding: %s", tc.file, errs[0].Text())
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkManyContainers validates a Pod with a long container list,
+// where per-container costs such as compiling a regexp add up.
+func BenchmarkManyContainers(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("apiVersion: v1\nkind: Pod\nmetadata:\n  name: big\nspec:\n  containers:\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&sb, "    - name: c%d\n      image: registry.example.com:5000/team/app-%d:1.%d\n", i, i, i)
+		fmt.Fprintf(&sb, "      ports:\n        - name: http-%d\n          containerPort: %d\n", i, 8000+i)
+		fmt.Fprintf(&sb, "      readinessProbe:\n        httpGet:\n          path: /ready\n          port: http-%d\n", i)
+	}
+	data := []byte(sb.String())
+	v := &Validator{}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if errs := v.ValidateBytes(data, "big.yaml"); len(errs) > 0 {
+			b.Fatalf("unexpected finding: %s", errs[0].Text())
+		}
+	}
+}
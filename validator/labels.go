@@ -0,0 +1,142 @@
+package validator
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxAnnotationsSize is the API server's limit on the combined size of
+// all annotation keys and values of one object.
+const maxAnnotationsSize = 256 * 1024
+
+// labelRules are reported by validateLabels.
+var labelRules = []Rule{
+	{
+		ID: "DOC013", Severity: SeverityError,
+		Summary:     "label has invalid key or value",
+		Description: "metadata.labels keys are an optional DNS-1123 subdomain prefix and '/' followed by a name of at most 63 alphanumerics, '-', '_' or '.', starting and ending with an alphanumeric. Values follow the same rule as the name, may be empty, and must be strings.",
+		Example:     "labels:\n  app: -web-\n  tier/: frontend",
+		Fix:         "Use keys like example.com/app and values like web-1; quote values such as true or 1.",
+	},
+	{
+		ID: "DOC014", Severity: SeverityError,
+		Summary:     "annotation has invalid key or value, or annotations are too large",
+		Description: "metadata.annotations keys follow the same syntax as label keys and values must be strings. Values may be any length, but all keys and values of one object together must stay within 256KiB.",
+		Example:     "annotations:\n  /description: web\n  replicas: 3",
+		Fix:         "Fix the key, quote non-string values, and move large data into a ConfigMap.",
+	},
+}
+
+// validateLabels checks metadata.labels (DOC013) and metadata.annotations
+// (DOC014). Both take qualified names as keys; label values are limited
+// like the name part of a key, annotation values only in total size.
+func validateLabels(mapping *yaml.Node, filename string) []ValidationError {
+	metaNode := findMapKey(mapping, "metadata")
+	if metaNode == nil || metaNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []ValidationError
+	if labels := findMapKey(metaNode, "labels"); labels != nil {
+		errs = append(errs, validateStringMap(labels, "labels", "DOC013", filename, func(k, val *yaml.Node) []ValidationError {
+			if msg := labelValueProblem(val.Value); msg != "" {
+				return []ValidationError{newFieldError(filename, val, "labels", "DOC013", "label value for '%s' %s", k.Value, msg)}
+			}
+			return nil
+		})...)
+	}
+	if annotations := findMapKey(metaNode, "annotations"); annotations != nil {
+		size := 0
+		errs = append(errs, validateStringMap(annotations, "annotations", "DOC014", filename, func(k, val *yaml.Node) []ValidationError {
+			size += len(k.Value) + len(val.Value)
+			return nil
+		})...)
+		if size > maxAnnotationsSize {
+			errs = append(errs, newFieldError(filename, annotations, "annotations", "DOC014", "annotations total %d bytes, more than %d", size, maxAnnotationsSize))
+		}
+	}
+	return errs
+}
+
+// validateStringMap checks that node maps qualified-name keys to strings,
+// handing each well-formed entry to checkValue.
+func validateStringMap(node *yaml.Node, field, rule, filename string, checkValue func(k, val *yaml.Node) []ValidationError) []ValidationError {
+	if node.Kind != yaml.MappingNode {
+		return []ValidationError{newFieldError(filename, node, field, rule, "%s must be object", field)}
+	}
+	noun := strings.TrimSuffix(field, "s")
+	var errs []ValidationError
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, val := node.Content[i], node.Content[i+1]
+		if msg := qualifiedNameProblem(k.Value); msg != "" {
+			errs = append(errs, newFieldError(filename, k, field, rule, "%s key '%s' %s", noun, k.Value, msg))
+			continue
+		}
+		if !isString(val) {
+			errs = append(errs, newFieldError(filename, val, field, rule, "%s value for '%s' must be string", noun, k.Value).withQuoteFix(val))
+			continue
+		}
+		errs = append(errs, checkValue(k, val)...)
+	}
+	return errs
+}
+
+// qualifiedNameProblem describes what is wrong with a label or annotation
+// key, an optional DNS-1123 subdomain prefix and a slash followed by a
+// name, or returns "" if it is valid.
+func qualifiedNameProblem(key string) string {
+	name := key
+	if i := strings.LastIndexByte(key, '/'); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if prefix == "" {
+			return "has empty prefix"
+		}
+		if len(prefix) > 253 {
+			return "has prefix longer than 253 characters"
+		}
+		if !isDNSSubdomain(prefix) {
+			return "has invalid prefix"
+		}
+	}
+	switch {
+	case name == "":
+		return "has empty name"
+	case len(name) > 63:
+		return "has name longer than 63 characters"
+	case !isLabelName(name):
+		return "has invalid format"
+	}
+	return ""
+}
+
+// labelValueProblem is qualifiedNameProblem for label values, which may
+// also be empty but never have a prefix.
+func labelValueProblem(value string) string {
+	switch {
+	case value == "":
+	case len(value) > 63:
+		return "is longer than 63 characters"
+	case !isLabelName(value):
+		return "has invalid format"
+	}
+	return ""
+}
+
+// isLabelName reports whether s is alphanumerics, '-', '_' or '.',
+// starting and ending with an alphanumeric.
+func isLabelName(s string) bool {
+	if s == "" || !isAlnum(s[0]) || !isAlnum(s[len(s)-1]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !isAlnum(c) && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
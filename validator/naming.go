@@ -0,0 +1,115 @@
+package validator
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TransformBasename     = "basename"
+	TransformFirstSegment = "first-segment"
+)
+
+func ValidNameTransform(t string) bool {
+	return t == TransformBasename || t == TransformFirstSegment
+}
+
+// Container name styles for Validator.ContainerNameStyle.
+const (
+	NameStyleRFC1123   = "rfc1123"
+	NameStyleSnakeCase = "snake_case"
+)
+
+func ValidNameStyle(s string) bool {
+	return s == NameStyleRFC1123 || s == NameStyleSnakeCase
+}
+
+// filenameToken derives the part of a file name that metadata.name is
+// expected to contain: the whole base name, or its first segment before
+// '-', '_' or '.' (frontend-pod.yaml -> frontend).
+func filenameToken(filename, transform string) string {
+	base := filepath.Base(filename)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	if transform == TransformFirstSegment {
+		if i := strings.IndexAny(base, "-_."); i > 0 {
+			base = base[:i]
+		}
+	}
+	return strings.ToLower(strings.ReplaceAll(base, "_", "-"))
+}
+
+// namingRules are reported by the name checks below.
+var namingRules = []Rule{
+	{
+		ID: "DOC007", Severity: SeverityWarning,
+		Summary:     "metadata.name does not match the file name",
+		Description: "With --name-matches-filename, metadata.name must contain a token derived from the file name, so manifests are easy to find by resource name.",
+		Example:     "# frontend-pod.yaml\nmetadata:\n  name: backend",
+		Fix:         "Rename the resource or the file so they agree.",
+	},
+	{
+		ID: "POD030", Severity: SeverityError,
+		Summary:     "container name has invalid format",
+		Description: "Names of containers, init containers and ephemeral containers are checked against --container-name-style: rfc1123 (the default, what the API server requires) accepts DNS-1123 labels, snake_case accepts lowercase letters, digits and '_'. Either way names are at most 63 characters.",
+		Example:     "containers:\n  - name: Web_Server",
+		Fix:         "Rename the container, e.g. web-server.",
+	},
+}
+
+func validateNameMatchesFilename(mapping *yaml.Node, transform, filename string) []ValidationError {
+	nameNode := findMapKey(findMapKey(mapping, "metadata"), "name")
+	if nameNode == nil || nameNode.Kind != yaml.ScalarNode || nameNode.Value == "" {
+		return nil
+	}
+	token := filenameToken(filename, transform)
+	if token == "" || strings.Contains(nameNode.Value, token) {
+		return nil
+	}
+	return []ValidationError{newFieldError(filename, nameNode, "name", "DOC007", "name '%s' does not match file name (expected it to contain '%s')", nameNode.Value, token)}
+}
+
+// validateContainerNames checks the names of all containerLists against
+// ContainerNameStyle. snake_case is a house style for teams that
+// render names into other systems; the API server itself only accepts
+// DNS-1123 labels.
+func (v *Validator) validateContainerNames(specNode *yaml.Node, filename string) []ValidationError {
+	valid := isDNSLabel
+	if v.ContainerNameStyle == NameStyleSnakeCase {
+		valid = isSnakeCase
+	}
+	var errs []ValidationError
+	for _, list := range containerLists {
+		conts := findMapKey(specNode, list)
+		if conts == nil || conts.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, contNode := range conts.Content {
+			nameNode := findMapKey(contNode, "name")
+			switch {
+			case nameNode == nil || nameNode.Kind != yaml.ScalarNode || nameNode.Value == "":
+			case len(nameNode.Value) > 63:
+				errs = append(errs, newFieldError(filename, nameNode, "name", "POD030", "container name is longer than 63 characters"))
+			case !valid(nameNode.Value):
+				errs = append(errs, newFieldError(filename, nameNode, "name", "POD030", "container name has invalid format '%s'", nameNode.Value))
+			}
+		}
+	}
+	return errs
+}
+
+// isSnakeCase reports whether s is lowercase letters, digits and '_',
+// starting with a letter.
+func isSnakeCase(s string) bool {
+	if len(s) == 0 || s[0] < 'a' || s[0] > 'z' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
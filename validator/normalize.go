@@ -0,0 +1,123 @@
+package validator
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// topLevelOrder is the canonical order of the well-known document keys;
+// any other keys keep their relative order after them.
+var topLevelOrder = []string{"apiVersion", "kind", "metadata", "spec"}
+
+// NormalizeFile re-emits every document of a file with canonical key
+// order, 2-space indentation and implicit defaults made explicit.
+// Comments survive because the yaml.Node tree is round-tripped as is.
+// A path of "-" reads stdin.
+func NormalizeFile(path string, w io.Writer) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var root yaml.Node
+		if err := dec.Decode(&root); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+			normalizeDocument(root.Content[0])
+		}
+		if err := enc.Encode(&root); err != nil {
+			return err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func normalizeDocument(mapping *yaml.Node) {
+	if mapping.Kind != yaml.MappingNode {
+		return
+	}
+	var ordered []*yaml.Node
+	for _, key := range topLevelOrder {
+		for i := 0; i < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == key {
+				ordered = append(ordered, mapping.Content[i], mapping.Content[i+1])
+			}
+		}
+	}
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if !contains(topLevelOrder, mapping.Content[i].Value) {
+			ordered = append(ordered, mapping.Content[i], mapping.Content[i+1])
+		}
+	}
+	// A comment heading the file belongs to the file, not to whichever
+	// key happened to come first
+	if len(ordered) > 0 && ordered[0] != mapping.Content[0] {
+		ordered[0].HeadComment, mapping.Content[0].HeadComment = mapping.Content[0].HeadComment, ""
+	}
+	mapping.Content = ordered
+
+	spec := findMapKey(mapping, "spec")
+	for _, list := range containerLists {
+		conts := findMapKey(spec, list)
+		if conts == nil || conts.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, contNode := range conts.Content {
+			if contNode.Kind != yaml.MappingNode {
+				continue
+			}
+			if image := scalarValue(contNode, "image"); image != "" && findMapKey(contNode, "imagePullPolicy") == nil {
+				setMapKey(contNode, "imagePullPolicy", defaultPullPolicy(image))
+			}
+			if ports := findMapKey(contNode, "ports"); ports != nil && ports.Kind == yaml.SequenceNode {
+				for _, p := range ports.Content {
+					if p.Kind == yaml.MappingNode && findMapKey(p, "protocol") == nil {
+						setMapKey(p, "protocol", "TCP")
+					}
+				}
+			}
+		}
+	}
+}
+
+// defaultPullPolicy mirrors the API server: Always for :latest or untagged
+// images, IfNotPresent otherwise.
+func defaultPullPolicy(image string) string {
+	if strings.Contains(image, "@") {
+		return "IfNotPresent"
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if !strings.Contains(name, ":") || strings.HasSuffix(name, ":latest") {
+		return "Always"
+	}
+	return "IfNotPresent"
+}
+
+func setMapKey(node *yaml.Node, key, value string) {
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
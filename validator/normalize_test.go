@@ -0,0 +1,43 @@
+package validator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestNormalizeFile checks key order, comments and the added
+// imagePullPolicy and protocol defaults against want.yaml, which must
+// itself come out unchanged.
+func TestNormalizeFile(t *testing.T) {
+	dir := filepath.Join("testdata", "normalize")
+	want, err := os.ReadFile(filepath.Join(dir, "want.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"input.yaml", "want.yaml"} {
+		var got bytes.Buffer
+		if err := NormalizeFile(filepath.Join(dir, name), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Bytes(), want) {
+			t.Errorf("%s: normalized output differs from want.yaml:\n%s", name, got.String())
+		}
+	}
+}
+
+func TestDefaultPullPolicy(t *testing.T) {
+	for image, want := range map[string]string{
+		"nginx":                         "Always",
+		"nginx:latest":                  "Always",
+		"nginx:1.25":                    "IfNotPresent",
+		"registry.example.com:5000/x":   "Always",
+		"registry.example.com:5000/x:1": "IfNotPresent",
+		"nginx@sha256:abc":              "IfNotPresent",
+	} {
+		if got := defaultPullPolicy(image); got != want {
+			t.Errorf("defaultPullPolicy(%q) = %s, want %s", image, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package validator
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setPaths fills in the Path of findings that point at a node of the
+// document rooted at root. The path index is only built when needed, so
+// valid documents cost nothing.
+func setPaths(root *yaml.Node, errs []ValidationError) {
+	var paths map[*yaml.Node]string
+	for i := range errs {
+		if errs[i].node == nil {
+			continue
+		}
+		if paths == nil {
+			paths = nodePaths(root)
+		}
+		errs[i].Path = paths[errs[i].node]
+	}
+}
+
+// nodePaths maps every node below root to its path. A mapping key gets
+// the same path as its value.
+func nodePaths(root *yaml.Node) map[*yaml.Node]string {
+	paths := make(map[*yaml.Node]string)
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		paths[n] = path
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				p := n.Content[i].Value
+				if path != "" {
+					p = path + "." + p
+				}
+				paths[n.Content[i]] = p
+				walk(n.Content[i+1], p)
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				walk(c, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	walk(root, "")
+	return paths
+}
@@ -0,0 +1,159 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policies evaluates Rego policies against each document with the opa
+// binary, merging their results into the findings: by the conftest
+// convention, results of the deny rule of package Namespace are reported
+// as POL001 and those of warn as POL002. A result is a message string, or
+// an object with msg and optionally path, e.g.
+// {"msg": "...", "path": "spec.containers[0].image"}, which is reported
+// at that node of the document.
+//
+// opa runs once per document, which is slow for thousands of them but
+// keeps OPA and its dependencies out of this binary. Call CheckPolicies
+// before validating.
+type Policies struct {
+	Dir       string
+	Namespace string
+}
+
+// policyResult is the output of opa eval --format json.
+type policyResult struct {
+	Result []struct {
+		Expressions []struct {
+			Value struct {
+				Deny []any `json:"deny"`
+				Warn []any `json:"warn"`
+			} `json:"value"`
+		} `json:"expressions"`
+	} `json:"result"`
+}
+
+// regoPackageRe matches a Rego package path such as main or k8s.admission.
+// The namespace goes into the query text, so nothing else is let through.
+var regoPackageRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// CheckPolicies makes sure the namespace is a package path, opa is
+// installed and the policies in p.Dir compile, so broken policies fail
+// once up front instead of on every document.
+func (p *Policies) CheckPolicies() error {
+	if !regoPackageRe.MatchString(p.Namespace) {
+		return fmt.Errorf("policy namespace '%s' is not a Rego package path such as main or k8s.admission", p.Namespace)
+	}
+	if _, err := exec.LookPath("opa"); err != nil {
+		return fmt.Errorf("--policy-dir needs the opa binary: %v", err)
+	}
+	out, err := exec.Command("opa", "check", p.Dir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// policyRules are reported for the results of the Rego policies.
+var policyRules = []Rule{
+	{
+		ID: "POL001", Severity: SeverityError,
+		Summary:     "Rego policy denied the document",
+		Description: "With --policy-dir, every document is evaluated with opa against the Rego policies in the directory, and each result of the deny rule in package main (or --policy-namespace) is reported. A result may be a message or an object with msg and path; the finding then points at that path of the document. Failures to evaluate a document are reported here too.",
+		Example:     "deny contains msg if {\n  input.kind == \"Pod\"\n  not input.metadata.labels.team\n  msg := \"pods must have a team label\"\n}",
+		Fix:         "Change the document to satisfy the policy, or ask its owners for an exception.",
+	},
+	{
+		ID: "POL002", Severity: SeverityWarning,
+		Summary:     "Rego policy warning",
+		Description: "Like POL001, for the results of the warn rule of the policy package.",
+		Example:     "warn contains msg if {\n  input.spec.hostNetwork\n  msg := \"hostNetwork is discouraged\"\n}",
+		Fix:         "Change the document if the warning applies.",
+	},
+}
+
+// validatePolicies evaluates the policies against the document mapping.
+func (p *Policies) validatePolicies(mapping *yaml.Node, filename string) []ValidationError {
+	var doc any
+	if err := mapping.Decode(&doc); err != nil {
+		return []ValidationError{newError(filename, mapping, "POL001", "policy input: %v", err)}
+	}
+	input, err := json.Marshal(doc)
+	if err != nil {
+		return []ValidationError{newError(filename, mapping, "POL001", "policy input: %v", err)}
+	}
+	query := fmt.Sprintf(`{"deny": [m | m := data.%[1]s.deny[_]], "warn": [m | m := data.%[1]s.warn[_]]}`, p.Namespace)
+	cmd := exec.Command("opa", "eval", "--format", "json", "--data", p.Dir, "--stdin-input", query)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return []ValidationError{newError(filename, mapping, "POL001", "policy evaluation failed: %s", strings.TrimSpace(stderr.String()+" "+err.Error()))}
+	}
+	var res policyResult
+	if err := json.Unmarshal(out, &res); err != nil {
+		return []ValidationError{newError(filename, mapping, "POL001", "policy evaluation failed: %v", err)}
+	}
+	var errs []ValidationError
+	var paths map[string]*yaml.Node
+	for _, r := range res.Result {
+		for _, e := range r.Expressions {
+			for _, set := range []struct {
+				rule    string
+				results []any
+			}{{"POL001", e.Value.Deny}, {"POL002", e.Value.Warn}} {
+				for _, result := range set.results {
+					msg, path := policyMessage(result)
+					node := mapping
+					if path != "" {
+						if paths == nil {
+							paths = pathNodes(mapping)
+						}
+						if n, ok := paths[path]; ok {
+							node = n
+						}
+					}
+					errs = append(errs, newError(filename, node, set.rule, "%s", msg))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// policyMessage reads a deny or warn result: a string, or an object with
+// msg and path.
+func policyMessage(result any) (msg, path string) {
+	switch r := result.(type) {
+	case string:
+		return r, ""
+	case map[string]any:
+		msg, _ = r["msg"].(string)
+		path, _ = r["path"].(string)
+		if msg != "" {
+			return msg, path
+		}
+	}
+	b, _ := json.Marshal(result)
+	return string(b), ""
+}
+
+// pathNodes maps the path of every node below root to the node, the
+// inverse of nodePaths. Where a mapping key and its value share a path,
+// the key wins since it comes first.
+func pathNodes(root *yaml.Node) map[string]*yaml.Node {
+	nodes := make(map[string]*yaml.Node)
+	for n, path := range nodePaths(root) {
+		if prev, ok := nodes[path]; !ok || n.Line < prev.Line || (n.Line == prev.Line && n.Column < prev.Column) {
+			nodes[path] = n
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,79 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// stubOpa puts a fake opa on PATH: check succeeds, and eval records its
+// arguments in the returned file and prints result.
+func stubOpa(t *testing.T, result string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the opa stub is a shell script")
+	}
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	script := "#!/bin/sh\nif [ \"$1\" = eval ]; then\n  echo \"$@\" > " + args + "\n  cat > /dev/null\n  echo '" + result + "'\nfi\n"
+	if err := os.WriteFile(filepath.Join(dir, "opa"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return args
+}
+
+func TestPolicyNamespace(t *testing.T) {
+	stubOpa(t, "")
+	for _, tc := range []struct {
+		namespace string
+		valid     bool
+	}{
+		{"main", true},
+		{"k8s.admission", true},
+		{"_private.v2", true},
+		{"", false},
+		{"k8s-admission", false},
+		{"main.", false},
+		{"main].deny[_], \"x\": data", false},
+	} {
+		t.Run(tc.namespace, func(t *testing.T) {
+			err := (&Policies{Dir: t.TempDir(), Namespace: tc.namespace}).CheckPolicies()
+			if (err == nil) != tc.valid {
+				t.Fatalf("got error %v, want valid %v", err, tc.valid)
+			}
+		})
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	args := stubOpa(t, `{"result": [{"expressions": [{"value": {"deny": ["no latest tags", {"msg": "untrusted image", "path": "spec.containers[0].image"}], "warn": ["no probes"]}}]}]}`)
+	p := &Policies{Dir: t.TempDir(), Namespace: "k8s.pods"}
+	if err := p.CheckPolicies(); err != nil {
+		t.Fatal(err)
+	}
+	errs := (&Validator{Policies: p}).ValidateBytes([]byte(podWith("")), "test.yaml")
+	var got []string
+	for _, e := range errs {
+		if strings.HasPrefix(e.Rule, "POL") {
+			got = append(got, e.Location()+e.Rule+" "+e.Message)
+		}
+	}
+	want := []string{
+		"test.yaml:1:1 POL001 no latest tags",
+		"test.yaml:1:1 POL002 no probes",
+		"test.yaml:8:7 POL001 untrusted image",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got findings\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	query, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(query), "data.k8s.pods.deny[_]") {
+		t.Fatalf("query does not use the namespace: %s", query)
+	}
+}
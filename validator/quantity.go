@@ -0,0 +1,183 @@
+package validator
+
+import (
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// quantitySuffixes maps the suffixes of a Kubernetes quantity to their
+// multipliers. Decimal exponents (1e3, 1E3) are handled separately.
+var quantitySuffixes = map[string]*big.Rat{
+	"n":  big.NewRat(1, 1000*1000*1000),
+	"u":  big.NewRat(1, 1000*1000),
+	"m":  big.NewRat(1, 1000),
+	"":   big.NewRat(1, 1),
+	"k":  big.NewRat(1000, 1),
+	"M":  big.NewRat(1000*1000, 1),
+	"G":  big.NewRat(1000*1000*1000, 1),
+	"T":  big.NewRat(1000*1000*1000*1000, 1),
+	"P":  big.NewRat(1000*1000*1000*1000*1000, 1),
+	"E":  big.NewRat(1000*1000*1000*1000*1000*1000, 1),
+	"Ki": big.NewRat(1<<10, 1),
+	"Mi": big.NewRat(1<<20, 1),
+	"Gi": big.NewRat(1<<30, 1),
+	"Ti": big.NewRat(1<<40, 1),
+	"Pi": big.NewRat(1<<50, 1),
+	"Ei": big.NewRat(1<<60, 1),
+}
+
+// ParseQuantity parses a Kubernetes quantity such as 500m, 1.5, 512Mi or
+// 1e3: an optionally signed decimal number followed by a suffix or a
+// decimal exponent. The value is kept exact, so 1Gi and 1024Mi compare
+// equal. The suffix is returned so callers can restrict the ones allowed.
+func ParseQuantity(s string) (value *big.Rat, suffix string, ok bool) {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := 0
+	for ; i < len(s) && isDigit(s[i]); i++ {
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		for ; i < len(s) && isDigit(s[i]); i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return nil, "", false
+	}
+	number, suffix := s[:i], s[i:]
+	if value = smallDecimal(number); value == nil {
+		if value, ok = new(big.Rat).SetString(number); !ok {
+			return nil, "", false
+		}
+	}
+	if mult, known := quantitySuffixes[suffix]; known {
+		return value.Mul(value, mult), suffix, true
+	}
+	exp, ok := quantityExponent(suffix)
+	if !ok {
+		return nil, "", false
+	}
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exp))), nil)
+	if exp < 0 {
+		return value.Quo(value, new(big.Rat).SetInt(pow)), suffix, true
+	}
+	return value.Mul(value, new(big.Rat).SetInt(pow)), suffix, true
+}
+
+// smallDecimal parses a decimal number of up to 18 digits without going
+// through big.Rat.SetString, which allocates far more. It returns nil for
+// longer numbers.
+func smallDecimal(number string) *big.Rat {
+	var n, denom int64 = 0, 1
+	neg := false
+	digits := 0
+	for i := 0; i < len(number); i++ {
+		switch c := number[i]; {
+		case c == '-':
+			neg = true
+		case c == '+':
+		case c == '.':
+			denom = 1
+			for j := i + 1; j < len(number); j++ {
+				denom *= 10
+			}
+		default:
+			if digits++; digits > 18 {
+				return nil
+			}
+			n = n*10 + int64(c-'0')
+		}
+	}
+	if neg {
+		n = -n
+	}
+	return big.NewRat(n, denom)
+}
+
+// quantityExponent parses a decimal exponent suffix like e3 or E-2.
+func quantityExponent(suffix string) (int, bool) {
+	if len(suffix) < 2 || (suffix[0] != 'e' && suffix[0] != 'E') {
+		return 0, false
+	}
+	rest := suffix[1:]
+	sign := 1
+	if rest[0] == '+' || rest[0] == '-' {
+		if rest[0] == '-' {
+			sign = -1
+		}
+		rest = rest[1:]
+	}
+	// Kubernetes keeps exponents well within this
+	if rest == "" || len(rest) > 2 || strings.TrimLeft(rest, "0123456789") != "" {
+		return 0, false
+	}
+	n := 0
+	for i := 0; i < len(rest); i++ {
+		n = n*10 + int(rest[i]-'0')
+	}
+	return sign * n, true
+}
+
+// ParseMemory converts a memory quantity such as 512Mi or 1G to bytes,
+// rounding fractions of a byte up the way the API server does. Negative
+// and out of range values are rejected.
+func ParseMemory(s string) (int64, bool) {
+	q, _, ok := ParseQuantity(s)
+	if !ok || q.Sign() < 0 {
+		return 0, false
+	}
+	n := new(big.Int).Quo(q.Num(), q.Denom())
+	if !q.IsInt() {
+		n.Add(n, big.NewInt(1))
+	}
+	if !n.IsInt64() {
+		return 0, false
+	}
+	return n.Int64(), true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// quantitySuffixFixes maps common misspellings of quantity suffixes, in
+// lower case, to the suffix meant: binary suffixes in the wrong case or
+// with a trailing B, and decimal byte units such as MB.
+var quantitySuffixFixes = map[string]string{
+	"ki": "Ki", "mi": "Mi", "gi": "Gi", "ti": "Ti", "pi": "Pi", "ei": "Ei",
+	"kib": "Ki", "mib": "Mi", "gib": "Gi", "tib": "Ti", "pib": "Pi", "eib": "Ei",
+	"kb": "k", "mb": "M", "gb": "G", "tb": "T", "pb": "P", "eb": "E",
+}
+
+// normalizeQuantity corrects a misspelled suffix such as 512mi, 2GiB or
+// 1GB, returning "" when s has no known misspelling.
+func normalizeQuantity(s string) string {
+	i := strings.IndexFunc(s, unicode.IsLetter)
+	if i <= 0 {
+		return ""
+	}
+	suffix, ok := quantitySuffixFixes[strings.ToLower(s[i:])]
+	if !ok && s[i:] == "K" {
+		suffix, ok = "k", true
+	}
+	if !ok {
+		return ""
+	}
+	fixed := s[:i] + suffix
+	if _, _, valid := ParseQuantity(fixed); !valid || fixed == s {
+		return ""
+	}
+	return fixed
+}
@@ -0,0 +1,179 @@
+package validator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	// SeverityInfo findings are shown but never fail a run.
+	SeverityInfo = "info"
+	SeverityOff  = "off"
+)
+
+// RulesetVersion identifies the behavior of the built-in rules. Bump it
+// whenever a rule is added or starts reporting different manifests, so
+// pipelines pinned with --rules-version notice the change.
+const RulesetVersion = 46
+
+// Rule describes a single validation check and its default severity.
+// Opt-in rules are only reported once enabled with --enable-rule or
+// given a severity in the rule config. Description, Example and Fix are
+// shown by --explain-rule.
+type Rule struct {
+	ID          string `json:"id"`
+	Severity    string `json:"severity"`
+	OptIn       bool   `json:"optIn,omitempty"`
+	Summary     string `json:"summary"`
+	Description string `json:"description,omitempty"`
+	Example     string `json:"example,omitempty"`
+	Fix         string `json:"fix,omitempty"`
+}
+
+// rules are the built-in rules. Each file declares the rules it reports
+// next to its checks.
+var rules = slices.Concat(
+	configMapRules,
+	envRules,
+	namingRules,
+	schemaRules,
+	labelRules,
+	keyRules,
+	jsonSchemaRules,
+	imageRules,
+	volumeRules,
+	securityRules,
+	policyRules,
+	serviceRules,
+	documentRules,
+	podRules,
+	containerRules,
+	probeRules,
+)
+
+var rulesByID = func() map[string]*Rule {
+	m := make(map[string]*Rule, len(rules))
+	for i := range rules {
+		m[rules[i].ID] = &rules[i]
+	}
+	return m
+}()
+
+func FindRule(id string) *Rule {
+	return rulesByID[id]
+}
+
+// Rules returns every rule, built-in and registered, sorted by id.
+func Rules() []Rule {
+	out := make([]Rule, 0, len(rulesByID))
+	for _, r := range rulesByID {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func validSeverity(s string) bool {
+	return s == SeverityError || s == SeverityWarning || s == SeverityInfo || s == SeverityOff
+}
+
+// LoadRuleConfig reads a mapping of rule ids to severities. The file is
+// parsed as YAML, so plain JSON objects are accepted as well.
+func LoadRuleConfig(path string) (map[string]string, []string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("Error reading rule config: %v", err)}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, []string{parseErrorText(path, "rule config", err)}
+	}
+	mapping := &root
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		mapping = root.Content[0]
+	}
+	if mapping.Kind == 0 {
+		// Empty file: nothing to override
+		return map[string]string{}, nil
+	}
+	if mapping.Kind != yaml.MappingNode {
+		return nil, []string{fmt.Sprintf("%s:%d rule config must be object", path, mapping.Line)}
+	}
+
+	var errs []string
+	severities := make(map[string]string)
+	for i := 0; i < len(mapping.Content); i += 2 {
+		k, v := mapping.Content[i], mapping.Content[i+1]
+		if FindRule(k.Value) == nil {
+			errs = append(errs, fmt.Sprintf("%s:%d unknown rule id '%s'", path, k.Line, k.Value))
+			continue
+		}
+		if v.Kind != yaml.ScalarNode || !validSeverity(v.Value) {
+			errs = append(errs, fmt.Sprintf("%s:%d rule %s has unsupported severity '%s'", path, v.Line, k.Value, v.Value))
+			continue
+		}
+		severities[k.Value] = v.Value
+	}
+	return severities, errs
+}
+
+// applySeverities resolves the effective severity of every finding and
+// drops the ones whose rule is switched off or not opted into.
+func applySeverities(errs []ValidationError, overrides map[string]string, enabled []string) []ValidationError {
+	if len(errs) == 0 {
+		return nil
+	}
+	var out []ValidationError
+	for _, e := range errs {
+		sev := SeverityError
+		if r := FindRule(e.Rule); r != nil {
+			sev = r.Severity
+			if r.OptIn && !contains(enabled, r.ID) {
+				sev = SeverityOff
+			}
+		}
+		if s, ok := overrides[e.Rule]; ok {
+			sev = s
+		}
+		if sev == SeverityOff {
+			continue
+		}
+		e.Severity = sev
+		out = append(out, e)
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ExplainRule prints the reference entry for a rule.
+func ExplainRule(w io.Writer, r *Rule) {
+	fmt.Fprintf(w, "%s: %s\n", r.ID, r.Summary)
+	severity := r.Severity
+	if r.OptIn {
+		severity += " (opt-in)"
+	}
+	fmt.Fprintf(w, "Severity: %s\n\n", severity)
+	fmt.Fprintf(w, "%s\n\n", r.Description)
+	fmt.Fprintf(w, "Example:\n%s\n\n", indent(r.Example, "    "))
+	fmt.Fprintf(w, "Fix: %s\n", r.Fix)
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
@@ -0,0 +1,383 @@
+package validator
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaSource holds the field definitions of the supported kinds; see
+// the comment at its top for the format.
+//
+//go:embed schemas.yaml
+var schemaSource []byte
+
+// schema describes the value of one field. A nil schema accepts anything
+// below it, for free-form maps like labels and for fields checked by
+// rules of their own.
+type schema struct {
+	Type      string             `yaml:"type"`
+	Required  bool               `yaml:"required"`
+	NonEmpty  bool               `yaml:"nonEmpty"`
+	Enum      []string           `yaml:"enum"`
+	Pattern   string             `yaml:"pattern"`
+	MaxLength int                `yaml:"maxLength"`
+	Fields    map[string]*schema `yaml:"fields"`
+	Items     *schema            `yaml:"items"`
+	Ref       string             `yaml:"ref"`
+	Rule      string             `yaml:"rule"`
+	Label     string             `yaml:"label"`
+	QuoteFix  bool               `yaml:"quoteFix"`
+
+	pattern *regexp.Regexp
+	// required lists the required Fields, sorted so findings on the
+	// same line come out in a stable order.
+	required []string
+	// resolved is the definition Ref names, with the settings given
+	// next to Ref applied.
+	resolved *schema
+	compiled bool
+}
+
+// schemaTypes maps the types a schema may declare to how they are named
+// in findings.
+var schemaTypes = map[string]string{
+	"string":      "string",
+	"int":         "int",
+	"bool":        "bool",
+	"intOrString": "int or string",
+	"object":      "object",
+	"array":       "array",
+}
+
+// schemaFile is the layout of schemas.yaml.
+type schemaFile struct {
+	Definitions  map[string]*schema `yaml:"definitions"`
+	KindMetadata map[string]string  `yaml:"kindMetadata"`
+	Kinds        map[string]*schema `yaml:"kinds"`
+}
+
+var (
+	schemas = loadSchemas(schemaSource)
+
+	metadataSchema  = schemas.Definitions["metadata"]
+	podSchema       = schemas.Kinds["Pod"]
+	configMapSchema = schemas.Kinds["ConfigMap"]
+	secretSchema    = schemas.Kinds["Secret"]
+	serviceSchema   = schemas.Kinds["Service"]
+)
+
+// loadSchemas parses the embedded definitions, checking their types and
+// references and compiling their patterns. They ship with the binary, so
+// a mistake in them is a bug and panics.
+func loadSchemas(src []byte) *schemaFile {
+	var f schemaFile
+	if err := yaml.Unmarshal(src, &f); err != nil {
+		panic(fmt.Sprintf("schemas.yaml: %v", err))
+	}
+	for _, set := range []map[string]*schema{f.Definitions, f.Kinds} {
+		for name, s := range set {
+			if err := s.compile(f.Definitions); err != nil {
+				panic(fmt.Sprintf("schemas.yaml: %s: %v", name, err))
+			}
+		}
+	}
+	for kind, name := range f.KindMetadata {
+		if _, ok := f.Definitions[name]; !ok {
+			panic(fmt.Sprintf("schemas.yaml: kindMetadata: %s: unknown definition %q", kind, name))
+		}
+	}
+	return &f
+}
+
+// metadataSchemaOf returns the metadata definition for documents of kind.
+func metadataSchemaOf(kind string) *schema {
+	if name, ok := schemas.KindMetadata[kind]; ok {
+		return schemas.Definitions[name]
+	}
+	return metadataSchema
+}
+
+// compile checks s and the schemas below it and compiles their patterns.
+// References are kept for resolve, which follows them to a copy of the
+// definition with the settings given next to the reference applied.
+func (s *schema) compile(defs map[string]*schema) error {
+	if s == nil || s.compiled {
+		return nil
+	}
+	s.compiled = true
+	if s.Rule != "" && FindRule(s.Rule) == nil {
+		return fmt.Errorf("unknown rule %q", s.Rule)
+	}
+	if s.Ref != "" {
+		def, ok := defs[s.Ref]
+		if !ok {
+			return fmt.Errorf("unknown definition %q", s.Ref)
+		}
+		if s.Type != "" || s.Enum != nil || s.Pattern != "" || s.Fields != nil || s.Items != nil || s.QuoteFix {
+			return fmt.Errorf("ref %q must not be combined with other settings", s.Ref)
+		}
+		if err := def.compile(defs); err != nil {
+			return fmt.Errorf("%s: %v", s.Ref, err)
+		}
+		r := *def
+		r.NonEmpty = r.NonEmpty || s.NonEmpty
+		if s.MaxLength != 0 {
+			r.MaxLength = s.MaxLength
+		}
+		if s.Rule != "" {
+			r.Rule = s.Rule
+		}
+		if s.Label != "" {
+			r.Label = s.Label
+		}
+		s.resolved = &r
+		return nil
+	}
+	if _, ok := schemaTypes[s.Type]; !ok && s.Type != "" {
+		return fmt.Errorf("unknown type %q", s.Type)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile("^(?:" + s.Pattern + ")$")
+		if err != nil {
+			return err
+		}
+		s.pattern = re
+	}
+	for name, f := range s.Fields {
+		// A required field that is present but empty is as good as missing
+		if f != nil && f.Required {
+			f.NonEmpty = true
+		}
+		if err := f.compile(defs); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if f != nil && f.Required {
+			s.required = append(s.required, name)
+		}
+	}
+	sort.Strings(s.required)
+	return s.Items.compile(defs)
+}
+
+// resolve follows a reference to the definition it names.
+func (s *schema) resolve() *schema {
+	if s != nil && s.Ref != "" {
+		return s.resolved
+	}
+	return s
+}
+
+// rule returns the rule findings on s are reported under.
+func (s *schema) rule() string {
+	if s.Rule != "" {
+		return s.Rule
+	}
+	return "DOC016"
+}
+
+// schemaRules are reported by checkSchema and the rule settings of
+// schemas.yaml.
+var schemaRules = []Rule{
+	{
+		ID: "DOC010", Severity: SeverityError,
+		Summary:     "unknown field in --strict mode",
+		Description: "With --strict, fields that do not exist at their level of a Pod, ConfigMap, Secret or Service are reported; the API server would drop or reject them. Every level with a fixed set of fields is checked, down to probe handlers, env sources, volume mounts and security contexts. Free-form maps such as labels, data or resource limits are not. When a known field is within a few edits of the unknown one, it is suggested.",
+		Example:     "spec:\n  continers:\n    - name: web",
+		Fix:         "Fix the spelling or the indentation of the field.",
+	},
+	{
+		ID: "DOC011", Severity: SeverityError,
+		Summary:     "name or namespace has invalid format",
+		Description: "metadata.namespace, and metadata.name of a Pod or Service, must be DNS-1123 labels: at most 63 lowercase alphanumerics or '-', starting and ending with an alphanumeric. Names of other kinds may be DNS-1123 subdomains: up to 253 characters of such labels joined by '.'. The API server rejects anything else.",
+		Example:     "metadata:\n  name: My_Pod",
+		Fix:         "Use lowercase letters, digits and dashes, e.g. my-pod.",
+	},
+	{
+		ID: "DOC016", Severity: SeverityError,
+		Summary:     "field does not match its schema",
+		Description: "The built-in schemas of Pod, ConfigMap, Secret and Service declare the type of fields, the values of enumerations such as restartPolicy, imagePullPolicy or dnsPolicy, the format of names like serviceAccountName, and which fields are required, such as spec.containers and the name of each container. Fields with a rule of their own, such as metadata.name, command or securityContext, are reported under that rule, and checks beyond type and format, such as those of image or containerPort, are left to it. A null value, or \"\" where a string is allowed, counts as leaving the field out.",
+		Example:     "spec:\n  restartPolicy: Sometimes\n  hostNetwork: \"yes\"",
+		Fix:         "Use a value of the listed type or one of the allowed values, and add missing required fields.",
+	},
+}
+
+// checkSchema checks node, the value of field, against s (DOC016 unless
+// s names another rule): its type, allowed values and format, and the
+// presence of required fields, down through the objects and arrays
+// below it. In strict mode the keys s does not list are reported as
+// well (DOC010).
+func (v *Validator) checkSchema(node *yaml.Node, s *schema, field, filename string) []ValidationError {
+	s = s.resolve()
+	if node == nil || s == nil {
+		return nil
+	}
+	if s.Label != "" {
+		field = s.Label
+	}
+	// Like the API server, treat null and, where strings are allowed, ""
+	// as leaving the field out
+	empty := node.Tag == "!!null" || node.Tag == "!!str" && node.Value == "" && (s.Type == "" || hasSchemaType(node, s.Type))
+	if node.Kind == yaml.ScalarNode && empty {
+		if s.NonEmpty {
+			return []ValidationError{newFieldError(filename, node, field, "POD014", "%s must not be empty", field)}
+		}
+		return nil
+	}
+	if s.Type == "" && node.Kind == yaml.SequenceNode {
+		var errs []ValidationError
+		for _, item := range node.Content {
+			errs = append(errs, v.checkSchema(item, s, field, filename)...)
+		}
+		return errs
+	}
+	if s.Type != "" && !hasSchemaType(node, s.Type) {
+		e := newFieldError(filename, node, field, s.rule(), "%s must be %s", field, schemaTypes[s.Type])
+		if s.QuoteFix {
+			e = e.withQuoteFix(node)
+		}
+		return []ValidationError{e}
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if s.Enum != nil && !contains(s.Enum, node.Value) {
+			return []ValidationError{newFieldError(filename, node, field, s.rule(), "%s has unsupported value '%s', allowed: %s", field, node.Value, strings.Join(s.Enum, ", "))}
+		}
+		if s.MaxLength > 0 && len(node.Value) > s.MaxLength {
+			return []ValidationError{newFieldError(filename, node, field, s.rule(), "%s is longer than %d characters", field, s.MaxLength)}
+		}
+		if s.pattern != nil && !s.pattern.MatchString(node.Value) {
+			return []ValidationError{newFieldError(filename, node, field, s.rule(), "%s has invalid format '%s'", field, node.Value)}
+		}
+	case yaml.SequenceNode:
+		var errs []ValidationError
+		for _, item := range node.Content {
+			errs = append(errs, v.checkSchema(item, s.Items, field+" entry", filename)...)
+		}
+		return errs
+	case yaml.MappingNode:
+		return v.checkFields(node, s, filename)
+	}
+	return nil
+}
+
+// checkFields checks the fields of an object against s.Fields. An
+// object schema without fields describes a free-form map.
+func (v *Validator) checkFields(node *yaml.Node, s *schema, filename string) []ValidationError {
+	if s.Fields == nil {
+		return nil
+	}
+	var errs []ValidationError
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k := node.Content[i]
+		sub, ok := s.Fields[k.Value]
+		if !ok {
+			if !v.Strict {
+				continue
+			}
+			if guess := s.suggest(k.Value); guess != "" {
+				errs = append(errs, newFieldError(filename, k, k.Value, "DOC010", "unknown field '%s', did you mean '%s'?", k.Value, guess))
+			} else {
+				errs = append(errs, newFieldError(filename, k, k.Value, "DOC010", "unknown field '%s'", k.Value))
+			}
+			continue
+		}
+		errs = append(errs, v.checkSchema(node.Content[i+1], sub, k.Value, filename)...)
+	}
+	for _, name := range s.required {
+		if findMapKeyNode(node, name) == nil {
+			sub, field := s.Fields[name].resolve(), name
+			if sub.Label != "" {
+				field = sub.Label
+			}
+			errs = append(errs, newFieldError(filename, node, field, sub.rule(), "%s is required", field))
+		}
+	}
+	return errs
+}
+
+// hasSchemaType reports whether node is a value of type typ.
+func hasSchemaType(node *yaml.Node, typ string) bool {
+	switch typ {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	}
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+	switch typ {
+	case "string":
+		return node.Tag == "!!str"
+	case "int":
+		return node.Tag == "!!int"
+	case "bool":
+		return node.Tag == "!!bool"
+	case "intOrString":
+		return node.Tag == "!!int" || node.Tag == "!!str"
+	}
+	return true
+}
+
+// suggest returns the field of s closest to name; see closestField.
+func (s *schema) suggest(name string) string {
+	known := make([]string, 0, len(s.Fields))
+	for f := range s.Fields {
+		known = append(known, f)
+	}
+	return closestField(name, known)
+}
+
+// closestField returns the known field closest to name, or "" when none
+// is close enough to be a likely typo: within a third of the name's
+// length in edits, and at most 3. Ties go to the alphabetically first
+// field so the suggestion does not depend on map order.
+func closestField(name string, known []string) string {
+	limit := len(name) / 3
+	if limit > 3 {
+		limit = 3
+	}
+	if limit < 1 {
+		limit = 1
+	}
+	sort.Strings(known)
+	best, bestDist := "", limit+1
+	for _, f := range known {
+		if d := editDistance(name, f); d < bestDist {
+			best, bestDist = f, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b, with a
+// swap of two adjacent bytes counting as one edit, since that is the most
+// common typo (nmae, tpye). Case changes count as edits too.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,57 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSchemaRules checks fields whose schema reports under a rule of its
+// own rather than DOC016, and references that override the rule or the
+// emptiness of the definition they name.
+func TestSchemaRules(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		rule string
+		want []string
+	}{
+		{"pod name is a label", "apiVersion: v1\nkind: Pod\nmetadata:\n  name: my.pod\n", "DOC011",
+			[]string{"name has invalid format 'my.pod'"}},
+		{"configmap name is a subdomain", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: my.config\n", "DOC011", nil},
+		{"empty name", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: \"\"\n", "POD014",
+			[]string{"name must not be empty"}},
+		{"empty namespace", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: \"\"\n", "DOC011", nil},
+		{"long namespace", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: " + strings.Repeat("a", 64) + "\n", "DOC011",
+			[]string{"namespace is longer than 63 characters"}},
+		{"empty container name", "apiVersion: v1\nkind: Pod\nmetadata:\n  name: a\nspec:\n  containers: [{name: \"\", image: \"nginx:1\"}]\n", "POD014",
+			[]string{"name must not be empty"}},
+		{"null required field", "apiVersion: v1\nkind: Pod\nmetadata:\n  name: a\nspec:\n  containers:\n", "POD014",
+			[]string{"containers must not be empty"}},
+		{"command entries", podWith("      command: [sh, 8080]\n"), "POD027",
+			[]string{"command entry must be string"}},
+		{"env entries", podWith("      env:\n        - value: x\n        - name: 5\n        - PORT\n"), "POD027",
+			[]string{"env entry name is required", "env entry name must be string", "env entry must be object"}},
+		{"port name", podWith("      ports:\n        - containerPort: 80\n          name: 8080\n"), "POD035",
+			[]string{"port name must be string"}},
+		{"probe exec", podWith("      livenessProbe:\n        exec: {command: check}\n"), "POD034",
+			[]string{"exec.command must be array"}},
+		{"probe exec entries", podWith("      livenessProbe:\n        exec: {command: [check, 3]}\n"), "POD034",
+			[]string{"exec.command entry must be string"}},
+		{"probe exec type", podWith("      livenessProbe:\n        exec: check\n"), "POD034",
+			[]string{"exec must be object"}},
+		{"null probe exec", podWith("      livenessProbe:\n        exec: ~\n"), "POD034",
+			[]string{"exec must be object"}},
+		{"capabilities", podWith("      securityContext:\n        capabilities:\n          drop: ALL\n"), "POD039",
+			[]string{"capabilities.drop must be array"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, e := range ruleFindings(t, tc.src, tc.rule) {
+				got = append(got, e.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Fatalf("got %s findings %q, want %q", tc.rule, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package validator
+
+import "gopkg.in/yaml.v3"
+
+// User and group ID fields of the pod and container security contexts.
+var (
+	podSecurityInts       = []string{"runAsUser", "runAsGroup", "fsGroup"}
+	containerSecurityInts = []string{"runAsUser", "runAsGroup"}
+)
+
+// securityRules are reported by validateSecurityContext.
+var securityRules = []Rule{
+	{
+		ID: "POD039", Severity: SeverityError,
+		Summary:     "securityContext field has wrong type",
+		Description: "In pod and container security contexts, runAsUser, runAsGroup, fsGroup and supplementalGroups are non-negative integer IDs; runAsNonRoot, privileged, readOnlyRootFilesystem and allowPrivilegeEscalation are booleans; capabilities.add and drop are lists of strings. A quoted \"true\" is a string, not a boolean.",
+		Example:     "securityContext:\n  runAsUser: \"1000\"\n  privileged: \"false\"",
+		Fix:         "Write IDs and booleans unquoted.",
+	},
+	{
+		ID: "POD040", Severity: SeverityError, OptIn: true,
+		Summary:     "privileged container",
+		Description: "A privileged container has full access to the host. Enable this rule, with --enable-rule or a severity in the project config, to forbid privileged: true in any container, init container or ephemeral container.",
+		Example:     "securityContext:\n  privileged: true",
+		Fix:         "Drop privileged and add only the capabilities the container needs.",
+	},
+	{
+		ID: "POD041", Severity: SeverityError, OptIn: true,
+		Summary:     "container may run as root",
+		Description: "Enable this rule, with --enable-rule or a severity in the project config, to require runAsNonRoot: true for every container, init container and ephemeral container, set either on the container or on the pod. A container setting overrides the pod's.",
+		Example:     "securityContext:\n  runAsNonRoot: false",
+		Fix:         "Set spec.securityContext.runAsNonRoot: true and a non-zero runAsUser in the image or manifest.",
+	},
+}
+
+// validateSecurityContext checks the user and group IDs of the pod and
+// container security contexts (POD039), whose other fields are checked
+// against the schema, and applies the opt-in policies that forbid
+// privileged containers (POD040) and require a non-root user (POD041).
+func validateSecurityContext(specNode *yaml.Node, filename string) []ValidationError {
+	podCtx := findMapKey(specNode, "securityContext")
+	errs := checkSecurityIDs(podCtx, podSecurityInts, filename)
+	for _, g := range sequenceItems(findMapKey(podCtx, "supplementalGroups")) {
+		errs = append(errs, checkIntRange(g, "supplementalGroups", 0, 1<<31-1, "POD039", "POD039", filename)...)
+	}
+	podNonRoot := findMapKey(podCtx, "runAsNonRoot")
+
+	for _, list := range containerLists {
+		for _, contNode := range sequenceItems(findMapKey(specNode, list)) {
+			ctx := findMapKey(contNode, "securityContext")
+			errs = append(errs, checkSecurityIDs(ctx, containerSecurityInts, filename)...)
+			if priv := findMapKey(ctx, "privileged"); priv != nil && priv.Tag == "!!bool" && priv.Value == "true" {
+				errs = append(errs, newFieldError(filename, priv, "privileged", "POD040", "container '%s' must not be privileged", scalarValue(contNode, "name")))
+			}
+			// The container setting wins over the pod's
+			nonRoot := podNonRoot
+			if n := findMapKey(ctx, "runAsNonRoot"); n != nil {
+				nonRoot = n
+			}
+			if nonRoot == nil || nonRoot.Tag != "!!bool" || nonRoot.Value != "true" {
+				node := nonRoot
+				if node == nil {
+					node = findMapKeyNode(contNode, "name")
+				}
+				if node == nil {
+					node = contNode
+				}
+				errs = append(errs, newFieldError(filename, node, "runAsNonRoot", "POD041", "container '%s' must set runAsNonRoot: true", scalarValue(contNode, "name")))
+			}
+		}
+	}
+	return errs
+}
+
+// checkSecurityIDs reports user and group ID fields of a security
+// context that are not non-negative ints.
+func checkSecurityIDs(ctx *yaml.Node, fields []string, filename string) []ValidationError {
+	var errs []ValidationError
+	for _, field := range fields {
+		if node := findMapKey(ctx, field); node != nil {
+			errs = append(errs, checkIntRange(node, field, 0, 1<<31-1, "POD039", "POD039", filename)...)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,119 @@
+package validator
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var serviceTypes = []string{"ClusterIP", "NodePort", "LoadBalancer", "ExternalName"}
+
+// serviceRules are reported by validateService.
+var serviceRules = []Rule{
+	{
+		ID: "SVC001", Severity: SeverityError,
+		Summary:     "service type has unsupported value",
+		Description: "spec.type of a Service must be ClusterIP (the default), NodePort, LoadBalancer or ExternalName.",
+		Example:     "spec:\n  type: Ingress",
+		Fix:         "Use one of the supported types; use an Ingress object for HTTP routing.",
+	},
+	{
+		ID: "SVC002", Severity: SeverityError,
+		Summary:     "service port is invalid",
+		Description: "Each spec.ports entry needs a port in 1-65535. targetPort is a port number or the name of a container port, nodePort is only valid for NodePort and LoadBalancer services and must lie in the default node port range 30000-32767, and protocol must be TCP, UDP or SCTP.",
+		Example:     "ports:\n  - port: 80\n    nodePort: 80",
+		Fix:         "Fix the number, or leave nodePort out to have one allocated.",
+	},
+	{
+		ID: "SVC003", Severity: SeverityError,
+		Summary:     "service selector must be a string map",
+		Description: "spec.selector matches pod labels, so it must map label keys to string values. Unquoted numbers and booleans are not strings.",
+		Example:     "selector:\n  version: 2",
+		Fix:         "Quote the value: version: \"2\".",
+	},
+}
+
+func validateService(mapping *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	specNode := findMapKey(mapping, "spec")
+	if specNode == nil {
+		return []ValidationError{newFieldError(filename, mapping, "spec", "DOC004", "spec is required")}
+	}
+	if specNode.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	svcType := "ClusterIP"
+	if typeNode := findMapKey(specNode, "type"); typeNode != nil {
+		if typeNode.Kind != yaml.ScalarNode {
+			errs = append(errs, newFieldError(filename, typeNode, "type", "SVC001", "type must be string"))
+		} else if !contains(serviceTypes, typeNode.Value) {
+			errs = append(errs, newFieldError(filename, typeNode, "type", "SVC001", "type has unsupported value '%s'", typeNode.Value))
+		} else {
+			svcType = typeNode.Value
+		}
+	}
+
+	if portsNode := findMapKey(specNode, "ports"); portsNode != nil {
+		errs = append(errs, validateServicePorts(portsNode, svcType, filename)...)
+	}
+
+	if selNode := findMapKey(specNode, "selector"); selNode != nil {
+		if selNode.Kind != yaml.MappingNode {
+			errs = append(errs, newFieldError(filename, selNode, "selector", "SVC003", "selector must be object"))
+		} else {
+			for i := 0; i+1 < len(selNode.Content); i += 2 {
+				if !isString(selNode.Content[i+1]) {
+					k := selNode.Content[i]
+					errs = append(errs, newFieldError(filename, selNode.Content[i+1], "selector", "SVC003", "selector value for '%s' must be string", k.Value).withQuoteFix(selNode.Content[i+1]))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// validateServicePorts checks port, targetPort (a number or a container
+// port name) and nodePort, which only node-exposed types may set.
+func validateServicePorts(portsNode *yaml.Node, svcType, filename string) []ValidationError {
+	if portsNode.Kind != yaml.SequenceNode {
+		return []ValidationError{newFieldError(filename, portsNode, "ports", "SVC002", "ports must be array")}
+	}
+	var errs []ValidationError
+	for _, portEntry := range portsNode.Content {
+		if portEntry.Kind != yaml.MappingNode {
+			errs = append(errs, newFieldError(filename, portEntry, "ports", "SVC002", "ports entry must be object"))
+			continue
+		}
+		portNode, reqErrs := requiredScalar(portEntry, "port", "port", "SVC002", filename)
+		errs = append(errs, reqErrs...)
+		if portNode != nil {
+			errs = append(errs, checkIntRange(portNode, "port", 1, 65535, "SVC002", "SVC002", filename)...)
+		}
+		if tpNode := findMapKey(portEntry, "targetPort"); tpNode != nil {
+			// A string targets a named container port
+			if !isString(tpNode) {
+				errs = append(errs, checkIntRange(tpNode, "targetPort", 1, 65535, "SVC002", "SVC002", filename)...)
+			}
+		}
+		if npNode := findMapKey(portEntry, "nodePort"); npNode != nil {
+			if svcType != "NodePort" && svcType != "LoadBalancer" {
+				errs = append(errs, newFieldError(filename, npNode, "nodePort", "SVC002", "nodePort is not valid for type %s", svcType))
+			} else {
+				errs = append(errs, checkIntRange(npNode, "nodePort", 30000, 32767, "SVC002", "SVC002", filename)...)
+			}
+		}
+		if protoNode := findMapKey(portEntry, "protocol"); protoNode != nil {
+			if protoNode.Kind != yaml.ScalarNode {
+				errs = append(errs, newFieldError(filename, protoNode, "protocol", "SVC002", "protocol must be string"))
+			} else if !contains(supportedProtocols, protoNode.Value) {
+				e := newFieldError(filename, protoNode, "protocol", "SVC002", "protocol has unsupported value '%s'", protoNode.Value)
+				if upper := strings.ToUpper(protoNode.Value); contains(supportedProtocols, upper) {
+					e = e.withValueFix(protoNode, upper)
+				}
+				errs = append(errs, e)
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,1829 @@
+// Package validator checks Kubernetes manifests for mistakes the API server
+// would reject or that are likely to break at runtime. The yamlvalid
+// command is a thin wrapper around it.
+package validator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError is a single finding reported for a manifest.
+type ValidationError struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column,omitempty"`
+	// Field is the name of the offending field, when there is one.
+	Field string `json:"field,omitempty"`
+	// Path locates the offending node in its document, e.g.
+	// spec.containers[0].image.
+	Path     string `json:"path,omitempty"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Fix is a suggested edit that repairs the finding, for rules with an
+	// obvious remediation; FixData applies them.
+	Fix *Fix `json:"fix,omitempty"`
+
+	// node is the node the finding points at, used to derive Path
+	node *yaml.Node
+}
+
+func (e ValidationError) String() string {
+	return e.Location() + e.Text()
+}
+
+// Location is the "file:line:column " prefix of the text form, the form
+// compilers use and editors jump to. Parts that are not known are left
+// out.
+func (e ValidationError) Location() string {
+	switch {
+	case e.Line == 0:
+		return e.File + ": "
+	case e.Column == 0:
+		return fmt.Sprintf("%s:%d ", e.File, e.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d ", e.File, e.Line, e.Column)
+}
+
+// Text is the message with its severity label, if not an error, and the
+// path of the offending node, which tells apart findings that only
+// differ in which container or port they are about.
+func (e ValidationError) Text() string {
+	text := e.Message
+	if e.Path != "" {
+		text = e.Path + ": " + text
+	}
+	if e.Severity != "" && e.Severity != SeverityError {
+		return e.Severity + ": " + text
+	}
+	return text
+}
+
+func newError(filename string, node *yaml.Node, rule, format string, args ...any) ValidationError {
+	return ValidationError{File: filename, Line: node.Line, Column: node.Column, Rule: rule, Message: fmt.Sprintf(format, args...), node: node}
+}
+
+var yamlErrorLineRe = regexp.MustCompile(`^yaml: line (\d+):`)
+
+// yamlErrorLine returns the line a YAML syntax error is on, or 0. The
+// parser does not report the column.
+func yamlErrorLine(err error) int {
+	if m := yamlErrorLineRe.FindStringSubmatch(err.Error()); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
+
+func newFieldError(filename string, node *yaml.Node, field, rule, format string, args ...any) ValidationError {
+	e := newError(filename, node, rule, format, args...)
+	e.Field = field
+	return e
+}
+
+// Result summarizes a validation run over one or more files. When the
+// findings are streamed to OnFinding they are not kept: Errors, Warnings
+// and Infos stay empty and Files carry no findings, so that memory does
+// not grow with them. Count is kept either way.
+type Result struct {
+	Errors    []ValidationError
+	Warnings  []ValidationError
+	Infos     []ValidationError
+	FileCount int
+	// FailedFiles is the number of files with errors.
+	FailedFiles int
+	// Documents is the number of documents validated in all files.
+	Documents int
+	Count     Counts
+	// Files keeps the findings grouped per input, in input order.
+	Files []FileResult
+}
+
+// Counts tallies findings by severity and rule.
+type Counts struct {
+	Errors   int
+	Warnings int
+	Infos    int
+	// ReadErrors counts the errors about input that could not be read
+	// (DOC002, DOC008).
+	ReadErrors int
+	ByRule     map[string]int
+}
+
+func countFindings(errs []ValidationError) Counts {
+	c := Counts{ByRule: make(map[string]int)}
+	for _, e := range errs {
+		switch e.Severity {
+		case SeverityError:
+			c.Errors++
+			if e.Rule == "DOC002" || e.Rule == "DOC008" {
+				c.ReadErrors++
+			}
+		case SeverityWarning:
+			c.Warnings++
+		default:
+			c.Infos++
+		}
+		c.ByRule[e.Rule]++
+	}
+	return c
+}
+
+// Add adds the tallies of o to c.
+func (c *Counts) Add(o Counts) {
+	c.Errors += o.Errors
+	c.Warnings += o.Warnings
+	c.Infos += o.Infos
+	c.ReadErrors += o.ReadErrors
+	if c.ByRule == nil {
+		c.ByRule = make(map[string]int)
+	}
+	for rule, n := range o.ByRule {
+		c.ByRule[rule] += n
+	}
+}
+
+// Add merges the result of one file into res, as ValidatePaths does.
+func (res *Result) Add(r FileResult) {
+	res.Files = append(res.Files, r)
+	res.FileCount++
+	res.Documents += r.Documents
+	res.Count.Add(r.Count)
+	if r.Count.Errors > 0 {
+		res.FailedFiles++
+	}
+	for _, e := range r.Errors {
+		switch e.Severity {
+		case SeverityError:
+			res.Errors = append(res.Errors, e)
+		case SeverityWarning:
+			res.Warnings = append(res.Warnings, e)
+		default:
+			res.Infos = append(res.Infos, e)
+		}
+	}
+}
+
+// FileResult holds the findings collected for a single input file.
+type FileResult struct {
+	File   string
+	Errors []ValidationError
+	// Count tallies Errors, once ValidatePaths has finished them.
+	Count Counts
+	// FromStdin is set when the input was read from stdin, so File is
+	// only a display name.
+	FromStdin bool
+	// Archive is the archive the file was read from, in which case File
+	// is the path of its entry.
+	Archive string
+	// Documents is the number of documents validated, not counting
+	// empty ones and those skipped by Kinds.
+	Documents int
+}
+
+// Failed reports whether any finding was an error after severities from
+// the rule config were applied.
+func (r Result) Failed() bool {
+	return r.Count.Errors > 0
+}
+
+// Validator holds the settings shared by every file of a run.
+type Validator struct {
+	Severities    map[string]string
+	EnabledRules  []string
+	SubstituteEnv bool
+	// Lenient accepts values that only differ from the expected form by
+	// letter case and normalizes them in place.
+	Lenient bool
+	// Kinds limits validation to documents of the listed kinds.
+	Kinds []string
+	// MaxCPU (cores) and MaxMemory (bytes) flag resource values that
+	// likely carry a units mistake; zero disables the check.
+	MaxCPU    int
+	MaxMemory int64
+	// Strict turns on opinionated best-practice checks and reports fields
+	// unknown at their level of the manifest.
+	Strict bool
+	// AllowedNamespaces, when set, restricts metadata.namespace. A missing
+	// namespace counts as "default" unless RequireNamespace is set.
+	AllowedNamespaces []string
+	RequireNamespace  bool
+	// AllowedRegistries, when set, restricts where images are pulled
+	// from; see validateRegistry for the pattern syntax.
+	AllowedRegistries []string
+	// QuantityUnits, when set, restricts the suffixes resource quantities
+	// may use; "" stands for a plain number.
+	QuantityUnits []string
+	// ContainerNameStyle is one of the NameStyle constants; empty means
+	// NameStyleRFC1123, which is what the API server enforces.
+	ContainerNameStyle string
+	// ImageTagPolicy is one of the TagPolicy constants; empty means any.
+	ImageTagPolicy string
+	// MaxFileSize skips inputs larger than this many bytes; zero means
+	// no limit.
+	MaxFileSize int64
+	// NameTransform, when set, requires metadata.name to contain a token
+	// derived from the file name; see filenameToken.
+	NameTransform string
+	// Filters drop findings for which any of them returns false, e.g.
+	// baselined findings or findings on unchanged lines.
+	Filters []func(ValidationError) bool
+	// StdinFilename is the file name reported for stdin; StdinName if
+	// empty.
+	StdinFilename string
+	// Schemas, when set, holds external definitions documents are checked
+	// against in addition to the built-in rules.
+	Schemas *SchemaSet
+	// Policies, when set, are Rego policies evaluated against every
+	// document.
+	Policies *Policies
+	// FailFast stops ValidatePaths at the first error: its file is the
+	// last one reported, with the findings up to that error.
+	FailFast bool
+	// Jobs is the number of files validated at once; zero or less means
+	// GOMAXPROCS. Rules registered with RegisterRule must be safe for
+	// concurrent use.
+	Jobs int
+	// OnFinding, when set, is called for each finding as soon as its file
+	// has been validated, so reports can be streamed. The findings are
+	// then only counted in the Result, not kept.
+	OnFinding func(ValidationError)
+}
+
+// Validate checks a manifest held in memory with the default rule set.
+// See (*Validator).Validate.
+func Validate(data []byte, filename string) ([]string, error) {
+	return (&Validator{}).Validate(data, filename)
+}
+
+// Validate checks a manifest held in memory and returns its findings in
+// the text form printed by the command, e.g. "pod.yaml:12 cpu has
+// invalid quantity '1x'". Findings of all documents of a multi-document
+// stream are returned together. The error is non-nil when data cannot be
+// parsed as YAML; the parse failure is also included in the findings.
+func (v *Validator) Validate(data []byte, filename string) ([]string, error) {
+	var out []string
+	var err error
+	for _, e := range v.ValidateBytes(data, filename) {
+		out = append(out, e.String())
+		if e.Rule == "DOC002" && err == nil {
+			err = errors.New(e.String())
+		}
+	}
+	return out, err
+}
+
+// ValidateBytes checks data like Validate but returns the findings
+// themselves, for callers such as editors that need their position.
+func (v *Validator) ValidateBytes(data []byte, filename string) []ValidationError {
+	if v.MaxFileSize > 0 && int64(len(data)) > v.MaxFileSize {
+		return v.finish(v.tooLarge(filename))
+	}
+	return v.finish(v.validateData(data, filename).Errors)
+}
+
+// StdinName is the default file name reported for input read from stdin,
+// which ValidatePaths does for the path "-".
+const StdinName = "<stdin>"
+
+func (v *Validator) stdinName() string {
+	if v.StdinFilename != "" {
+		return v.StdinFilename
+	}
+	return StdinName
+}
+
+// ValidatePaths checks the given files with the default rule set.
+func ValidatePaths(paths ...string) Result {
+	return (&Validator{}).ValidatePaths(paths...)
+}
+
+// ValidatePaths checks the given files and returns the findings split by
+// severity. Directories are searched recursively for .yaml and .yml files.
+// Files are validated by Jobs workers at once, but results and OnFinding
+// calls come in the order of paths, as if they were validated one by one,
+// which also makes FailFast stop at the same file on every run.
+func (v *Validator) ValidatePaths(paths ...string) Result {
+	// A file reached twice, e.g. via "dir dir/*.yaml", is validated once
+	seen := make(map[string]bool)
+	var tasks []*fileTask
+	for _, path := range paths {
+		switch {
+		case path == "-":
+			r := v.validateReader(os.Stdin, v.stdinName(), 0)
+			r.FromStdin = true
+			tasks = append(tasks, doneTask(r))
+		case isArchive(path):
+			for _, r := range v.validateArchive(path) {
+				tasks = append(tasks, doneTask(r))
+			}
+		case isDir(path):
+			tasks = append(tasks, dirTasks(path, seen)...)
+		case seen[pathKey(path)]:
+		default:
+			seen[pathKey(path)] = true
+			tasks = append(tasks, &fileTask{path: path, done: make(chan struct{})})
+		}
+	}
+	stop := v.runTasks(tasks)
+	defer stop()
+
+	var res Result
+	for _, t := range tasks {
+		<-t.done
+		r := t.result
+		// Filters may keep state, so findings are finished here, in order
+		r.Errors = v.finish(r.Errors)
+		failed := false
+		if v.FailFast {
+			for i, e := range r.Errors {
+				if e.Severity == SeverityError {
+					r.Errors = r.Errors[:i+1]
+					failed = true
+					break
+				}
+			}
+		}
+		r.Count = countFindings(r.Errors)
+		if v.OnFinding != nil {
+			for _, e := range r.Errors {
+				v.OnFinding(e)
+			}
+			r.Errors = nil
+		}
+		res.Add(r)
+		// The task is done with; let its findings go
+		t.result = FileResult{}
+		if failed {
+			break
+		}
+	}
+	return res
+}
+
+// fileTask is a file to validate; done is closed once result is set.
+type fileTask struct {
+	path   string
+	result FileResult
+	done   chan struct{}
+}
+
+// doneTask wraps a result that needs no more work.
+func doneTask(r FileResult) *fileTask {
+	t := &fileTask{result: r, done: make(chan struct{})}
+	close(t.done)
+	return t
+}
+
+// runTasks starts validating the files of tasks in the background, Jobs
+// at a time. Calling the returned function stops it from starting on
+// more files.
+func (v *Validator) runTasks(tasks []*fileTask) (stop func()) {
+	jobs := v.Jobs
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	queue := make(chan *fileTask)
+	for i := 0; i < jobs; i++ {
+		go func() {
+			for t := range queue {
+				t.result = v.validateFile(t.path)
+				close(t.done)
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(queue)
+		for _, t := range tasks {
+			if t.path == "" {
+				continue
+			}
+			select {
+			case queue <- t:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// finish applies the configured severities and filters to the raw
+// findings of one file and orders them by line. Findings without a line
+// come first; ties keep the order in which they were found.
+func (v *Validator) finish(errs []ValidationError) []ValidationError {
+	errs = v.filter(applySeverities(errs, v.Severities, v.EnabledRules))
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Line < errs[j].Line
+	})
+	return errs
+}
+
+func (v *Validator) filter(errs []ValidationError) []ValidationError {
+	if len(v.Filters) == 0 {
+		return errs
+	}
+	var out []ValidationError
+next:
+	for _, e := range errs {
+		for _, keep := range v.Filters {
+			if !keep(e) {
+				continue next
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func (v *Validator) validateFile(path string) FileResult {
+	f, err := os.Open(path)
+	if err != nil {
+		return FileResult{File: path, Errors: []ValidationError{{File: path, Rule: "DOC002", Message: fmt.Sprintf("Error reading file: %v", err)}}}
+	}
+	defer f.Close()
+	var size int64
+	if fi, err := f.Stat(); err == nil {
+		size = fi.Size()
+	}
+	return v.validateReader(f, path, size)
+}
+
+func (v *Validator) validateReader(r io.Reader, filename string, sizeHint int64) FileResult {
+	data, errs := v.readInput(r, filename, sizeHint)
+	if errs != nil {
+		return FileResult{File: filename, Errors: errs}
+	}
+	return v.validateData(data, filename)
+}
+
+// readInput reads at most MaxFileSize bytes, so a huge input is rejected
+// without being loaded into memory first. A known sizeHint rejects an
+// oversized file without reading it at all.
+func (v *Validator) readInput(r io.Reader, filename string, sizeHint int64) ([]byte, []ValidationError) {
+	if v.MaxFileSize > 0 {
+		if sizeHint > v.MaxFileSize {
+			return nil, v.tooLarge(filename)
+		}
+		r = io.LimitReader(r, v.MaxFileSize+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, []ValidationError{{File: filename, Rule: "DOC002", Message: fmt.Sprintf("Error reading file: %v", err)}}
+	}
+	if v.MaxFileSize > 0 && int64(len(data)) > v.MaxFileSize {
+		return nil, v.tooLarge(filename)
+	}
+	return data, nil
+}
+
+func (v *Validator) tooLarge(filename string) []ValidationError {
+	return []ValidationError{{File: filename, Rule: "DOC008", Message: fmt.Sprintf("file exceeds size limit of %d bytes", v.MaxFileSize)}}
+}
+
+// resourceID identifies a resource for apply purposes.
+type resourceID struct {
+	kind, name, namespace string
+}
+
+// documentRules are reported for files and whole documents.
+var documentRules = []Rule{
+	{
+		ID: "DOC002", Severity: SeverityError,
+		Summary:     "file cannot be read or parsed",
+		Description: "The file could not be read or is not valid YAML, so none of its documents were validated.",
+		Example:     "containers: [",
+		Fix:         "Fix the YAML syntax error at the reported position.",
+	},
+	{
+		ID: "DOC004", Severity: SeverityError,
+		Summary:     "spec is required",
+		Description: "A Pod without a spec has no containers to run.",
+		Example:     "kind: Pod\nmetadata:\n  name: web",
+		Fix:         "Add a spec with at least one container.",
+	},
+	{
+		ID: "DOC005", Severity: SeverityError,
+		Summary:     "document must be object",
+		Description: "Every document in the stream must be a mapping with apiVersion, kind and the resource fields; a bare list or scalar cannot be applied.",
+		Example:     "- name: web\n  image: nginx",
+		Fix:         "Wrap the content in a full resource manifest.",
+	},
+	{
+		ID: "DOC006", Severity: SeverityError,
+		Summary:     "duplicate resource in file",
+		Description: "Two documents with the same kind, name and namespace overwrite each other on apply.",
+		Example:     "kind: Pod\nmetadata: {name: web}\n---\nkind: Pod\nmetadata: {name: web}",
+		Fix:         "Rename one of the resources or drop the duplicate document.",
+	},
+	{
+		ID: "DOC008", Severity: SeverityError,
+		Summary:     "file exceeds size limit",
+		Description: "Inputs larger than --max-file-size are skipped without being read, to protect against huge or malicious files.",
+		Example:     "a 2GB generated manifest",
+		Fix:         "Split the file or raise --max-file-size.",
+	},
+	{
+		ID: "DOC009", Severity: SeverityError,
+		Summary:     "namespace is not allowed",
+		Description: "With --allowed-namespaces, manifests may only target the listed namespaces. A missing namespace means default, or is an error with --require-namespace.",
+		Example:     "metadata:\n  namespace: kube-system",
+		Fix:         "Deploy into one of your team's namespaces.",
+	},
+	{
+		ID: "DOC012", Severity: SeverityInfo,
+		Summary:     "document kind is missing or not supported",
+		Description: "Only the kinds with built-in checks (v1 Pod, ConfigMap, Secret and Service) or an external schema loaded with --schema-dir, --schema-url or --crd-dir are validated in depth. Other documents only get the checks that apply to every document, such as those on metadata.",
+		Example:     "apiVersion: apps/v1\nkind: Deployment",
+		Fix:         "Nothing to fix if the kind is intended; set this rule to off to silence it.",
+	},
+	{
+		ID: "POD007", Severity: SeverityError,
+		Summary:     "spec-level field found under metadata",
+		Description: "A spec or containers key inside metadata is almost always a block indented one level too deep, which leaves the pod without a spec.",
+		Example:     "metadata:\n  name: web\n  spec:\n    containers: []",
+		Fix:         "Dedent the block so spec is a top-level key.",
+	},
+}
+
+// validateData validates every document of a YAML stream.
+func (v *Validator) validateData(data []byte, filename string) FileResult {
+	var envErrs []ValidationError
+	source := data
+	if v.SubstituteEnv {
+		data, envErrs = substituteEnv(data, filename)
+		// Fixes are edits of the source, which no longer matches data
+		source = nil
+	}
+
+	errs := envErrs
+	docs := 0
+	// kind/namespace/name of every document, to catch apply-time collisions
+	var seen map[resourceID]bool
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var root yaml.Node
+		if err := dec.Decode(&root); err != nil {
+			if err != io.EOF {
+				errs = append(errs, ValidationError{File: filename, Line: yamlErrorLine(err), Rule: "DOC002", Message: fmt.Sprintf("Error parsing YAML: %v", err)})
+			}
+			break
+		}
+
+		// The decoder yields one DocumentNode per document; anything else
+		// means the stream could not be read as documents at all
+		if root.Kind != yaml.DocumentNode {
+			errs = append(errs, ValidationError{File: filename, Line: root.Line, Column: root.Column, Rule: "DOC005", Message: "unexpected YAML structure, expected a document"})
+			break
+		}
+		if len(root.Content) == 0 {
+			continue
+		}
+		mapping := root.Content[0]
+		if mapping.Kind == yaml.ScalarNode && mapping.Tag == "!!null" {
+			// Empty document, e.g. between two "---" separators
+			continue
+		}
+		if mapping.Kind != yaml.MappingNode {
+			errs = append(errs, newError(filename, mapping, "DOC005", "document must be object"))
+			continue
+		}
+
+		// Documents of other kinds are skipped, not reported
+		if len(v.Kinds) > 0 && !contains(v.Kinds, scalarValue(mapping, "kind")) {
+			continue
+		}
+		docs++
+		docStart := len(errs)
+		errs = append(errs, validateDuplicateKeys(mapping, filename)...)
+		errs = append(errs, v.validateDocument(mapping, filename)...)
+		errs = append(errs, validateCustomRules(mapping, filename)...)
+		if v.Policies != nil {
+			errs = append(errs, v.Policies.validatePolicies(mapping, filename)...)
+		}
+
+		metaNode := findMapKey(mapping, "metadata")
+		if nameNode := findMapKey(metaNode, "name"); nameNode != nil && nameNode.Kind == yaml.ScalarNode && nameNode.Value != "" {
+			kind := scalarValue(mapping, "kind")
+			namespace := scalarValue(metaNode, "namespace")
+			if namespace == "" {
+				namespace = "default"
+			}
+			id := resourceID{kind, nameNode.Value, namespace}
+			if seen[id] {
+				errs = append(errs, newFieldError(filename, nameNode, "name", "DOC006", "duplicate resource %s/%s in namespace %s", id.kind, id.name, id.namespace))
+			}
+			if seen == nil {
+				seen = make(map[resourceID]bool)
+			}
+			seen[id] = true
+		}
+		// Findings of rules that are off would only be dropped by finish;
+		// dropping them now spares building paths and fixes for them
+		errs = append(errs[:docStart], applySeverities(errs[docStart:], v.Severities, v.EnabledRules)...)
+		setPaths(mapping, errs[docStart:])
+	}
+	setFixRanges(source, errs)
+	return FileResult{File: filename, Errors: errs, Documents: docs}
+}
+
+func (v *Validator) validateDocument(mapping *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+
+	errs = append(errs, validateMetadata(mapping, filename)...)
+	errs = append(errs, validateLabels(mapping, filename)...)
+	if len(v.AllowedNamespaces) > 0 {
+		errs = append(errs, v.validateNamespace(mapping, filename)...)
+	}
+	if v.NameTransform != "" {
+		errs = append(errs, validateNameMatchesFilename(mapping, v.NameTransform, filename)...)
+	}
+	errs = append(errs, v.checkSchema(findMapKey(mapping, "metadata"), metadataSchemaOf(scalarValue(mapping, "kind")), "metadata", filename)...)
+
+	gvk := documentKind(mapping)
+	external := v.Schemas.lookup(gvk)
+	if external != nil {
+		errs = append(errs, v.checkJSONSchema(mapping, external, "", filename)...)
+	}
+	validate := lookupKind(gvk)
+	if validate == nil {
+		if external != nil {
+			return errs
+		}
+		if gvk.Kind == "" {
+			return append(errs, newFieldError(filename, mapping, "kind", "DOC012", "kind is missing, only generic checks applied"))
+		}
+		return append(errs, newFieldError(filename, findMapKey(mapping, "kind"), "kind", "DOC012", "unsupported kind %s, only generic checks applied", gvk))
+	}
+	return append(errs, validate(v, mapping, filename)...)
+}
+
+// podRules are reported by the checks of a pod spec.
+var podRules = []Rule{
+	{
+		ID: "POD001", Severity: SeverityError,
+		Summary:     "os has unsupported value",
+		Description: "Kubernetes only schedules pods for the linux and windows operating systems.",
+		Example:     "os: macos",
+		Fix:         "Set spec.os (or spec.os.name) to linux or windows.",
+	},
+	{
+		ID: "POD002", Severity: SeverityError,
+		Summary:     "os.name is required",
+		Description: "When spec.os is written as an object it must name the operating system.",
+		Example:     "os: {}",
+		Fix:         "Add name: linux or name: windows under spec.os.",
+	},
+	{
+		ID: "POD003", Severity: SeverityError,
+		Summary:     "os.name must be string",
+		Description: "spec.os.name is a plain string, not a list or object.",
+		Example:     "os:\n  name: [linux]",
+		Fix:         "Write the operating system as a scalar: name: linux.",
+	},
+	{
+		ID: "POD004", Severity: SeverityError,
+		Summary:     "os must be string or object",
+		Description: "spec.os is either a bare operating system name or an object with a name field.",
+		Example:     "os: [linux]",
+		Fix:         "Use os: linux or os: {name: linux}.",
+	},
+	{
+		ID: "POD008", Severity: SeverityError,
+		Summary:     "Deployment-only field set on a Pod",
+		Description: "replicas and selector belong to workload controllers such as Deployment; a Pod rejects them.",
+		Example:     "kind: Pod\nspec:\n  replicas: 3",
+		Fix:         "Remove the field or change the kind to Deployment and move the pod spec under spec.template.",
+	},
+	{
+		ID: "POD009", Severity: SeverityWarning, OptIn: true,
+		Summary:     "terminationGracePeriodSeconds shorter than probe failure window",
+		Description: "If the grace period is shorter than periodSeconds * failureThreshold of a probe, the pod can be killed before its probes settle, cutting shutdown short.",
+		Example:     "terminationGracePeriodSeconds: 5\nlivenessProbe:\n  periodSeconds: 10",
+		Fix:         "Raise terminationGracePeriodSeconds or tighten the probe timing.",
+	},
+	{
+		ID: "POD014", Severity: SeverityError,
+		Summary:     "required field must not be empty",
+		Description: "A required field is present but has an empty value, which the API server treats as missing.",
+		Example:     "os:\n  name: \"\"",
+		Fix:         "Fill in the value.",
+	},
+	{
+		ID: "POD020", Severity: SeverityError,
+		Summary:     "container name used in more than one container list",
+		Description: "Container names must be unique across containers, initContainers and ephemeralContainers of a pod.",
+		Example:     "initContainers:\n  - name: web\ncontainers:\n  - name: web",
+		Fix:         "Give the init container its own name, e.g. web-init.",
+	},
+	{
+		ID: "POD021", Severity: SeverityWarning, OptIn: true,
+		Summary:     "emptyDir without size or ephemeral-storage limits",
+		Description: "An emptyDir without sizeLimit, in a pod whose containers set no ephemeral-storage limit, can grow until the node runs out of disk and starts evicting pods.",
+		Example:     "volumes:\n  - name: cache\n    emptyDir: {}",
+		Fix:         "Set emptyDir.sizeLimit or resources.limits.ephemeral-storage on every container.",
+	},
+	{
+		ID: "POD022", Severity: SeverityInfo, OptIn: true,
+		Summary:     "cpu values mix millicores and whole cores",
+		Description: "Writing some cpu values as millicores (500m) and others as cores (1) in one pod is valid but makes them hard to compare.",
+		Example:     "requests:\n  cpu: 500m\n...\nrequests:\n  cpu: 1",
+		Fix:         "Pick one form for the pod, e.g. 1000m instead of 1.",
+	},
+	{
+		ID: "POD024", Severity: SeverityWarning, OptIn: true,
+		Summary:     "port name maps to different numbers across containers",
+		Description: "A Service that selects a port by name expects the name to mean one number. The same name on different containerPorts in one pod is usually a copy-paste mistake.",
+		Example:     "containers:\n  - ports:\n      - name: http\n        containerPort: 8080\n  - ports:\n      - name: http\n        containerPort: 9090",
+		Fix:         "Use the same number for the name, or give each port its own name.",
+	},
+}
+
+func (v *Validator) validatePod(mapping *yaml.Node, filename string) []ValidationError {
+	errs := v.checkSchema(mapping, podSchema, "", filename)
+	specNode := findMapKey(mapping, "spec")
+	if specNode == nil {
+		return append(errs, newFieldError(filename, mapping, "spec", "DOC004", "spec is required"))
+	}
+	if specNode.Kind == yaml.MappingNode {
+		errs = append(errs, v.validateSpec(specNode, filename)...)
+	}
+	return errs
+}
+
+func (v *Validator) validateSpec(specNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+
+	// Deployment fields pasted into a Pod are a common mix-up
+	for _, key := range []string{"replicas", "selector"} {
+		if keyNode := findMapKeyNode(specNode, key); keyNode != nil {
+			errs = append(errs, newFieldError(filename, keyNode, key, "POD008", "%s is not valid for kind Pod (did you mean Deployment?)", key))
+		}
+	}
+
+	// Validate spec.os
+	errs = append(errs, validateOS(specNode, filename)...)
+
+	errs = append(errs, validateGracePeriod(specNode, filename)...)
+
+	errs = append(errs, validateContainerNameOverlap(specNode, filename)...)
+	errs = append(errs, v.validateContainerNames(specNode, filename)...)
+	errs = append(errs, validateVolumes(specNode, filename)...)
+	errs = append(errs, validateSecurityContext(specNode, filename)...)
+	errs = append(errs, validateEmptyDirLimits(specNode, filename)...)
+	errs = append(errs, validateCPUUnitConsistency(specNode, filename)...)
+	errs = append(errs, validatePortNameConsistency(specNode, filename)...)
+
+	for _, list := range containerLists {
+		errs = append(errs, v.validateContainers(specNode, list, filename)...)
+	}
+	return errs
+}
+
+// containerLists are the fields of a pod spec holding containers. They
+// share the container checks, with the differences in
+// validateContainerKind.
+var containerLists = []string{"initContainers", "containers", "ephemeralContainers"}
+
+// containerRules are reported by the checks of a single container.
+var containerRules = []Rule{
+	{
+		ID: "POD006", Severity: SeverityError,
+		Summary:     "resource quantity has invalid format",
+		Description: "Resource requests and limits are Kubernetes quantities: a non-negative decimal number with an optional suffix, either decimal (m, k, M, G, T, P, E), binary (Ki, Mi, Gi, Ti, Pi, Ei) or an exponent such as e3. cpu is usually written in cores (0.5, 2) or millicores (500m), memory in bytes with a binary suffix (512Mi).",
+		Example:     "resources:\n  requests:\n    cpu: 500mc\n    memory: 1GB",
+		Fix:         "Use a valid suffix, e.g. cpu: 500m and memory: 1Gi.",
+	},
+	{
+		ID: "POD010", Severity: SeverityError,
+		Summary:     "ports must be an array of objects",
+		Description: "Container ports are declared as a list of objects with a containerPort field.",
+		Example:     "ports: 80",
+		Fix:         "Use ports: [{containerPort: 80}].",
+	},
+	{
+		ID: "POD011", Severity: SeverityError,
+		Summary:     "containerPort is required; container and host ports must be int",
+		Description: "Every ports entry needs a numeric containerPort; hostPort, when set, is numeric too.",
+		Example:     "ports:\n  - containerPort: \"80\"",
+		Fix:         "Write the port as an unquoted integer.",
+	},
+	{
+		ID: "POD012", Severity: SeverityError,
+		Summary:     "containerPort or hostPort value out of range",
+		Description: "Port numbers must be between 1 and 65535.",
+		Example:     "containerPort: 70000",
+		Fix:         "Use a valid port number.",
+	},
+	{
+		ID: "POD013", Severity: SeverityError,
+		Summary:     "protocol must be TCP, UDP or SCTP",
+		Description: "Kubernetes accepts only the uppercase protocol names TCP, UDP and SCTP.",
+		Example:     "protocol: tcp",
+		Fix:         "Use the uppercase name, or run with --lenient to normalize case.",
+	},
+	{
+		ID: "POD015", Severity: SeverityError,
+		Summary:     "duplicate containerPort/protocol pair in a container",
+		Description: "A container cannot declare the same port and protocol twice; a missing protocol counts as TCP.",
+		Example:     "ports:\n  - containerPort: 80\n  - containerPort: 80\n    protocol: TCP",
+		Fix:         "Remove the duplicate entry.",
+	},
+	{
+		ID: "POD016", Severity: SeverityWarning,
+		Summary:     "resource value exceeds sane maximum",
+		Description: "A cpu or memory value above --max-cpu/--max-memory usually means a units mistake, and the pod will stay unschedulable.",
+		Example:     "resources:\n  limits:\n    memory: 8000Gi",
+		Fix:         "Check the units (8Gi rather than 8000Gi) or raise the maximum.",
+	},
+	{
+		ID: "POD025", Severity: SeverityError,
+		Summary:     "duplicate container name",
+		Description: "Containers of a pod are addressed by name (logs, exec, status), so the API server rejects two containers with the same name.",
+		Example:     "containers:\n  - name: web\n  - name: web",
+		Fix:         "Rename one of the containers, e.g. web-sidecar.",
+	},
+	{
+		ID: "POD026", Severity: SeverityError,
+		Summary:     "resource requests exceed limits",
+		Description: "A container cannot be guaranteed more cpu or memory than it is allowed to use; the API server rejects requests above the matching limit. Quantities are compared by value, so 1024Mi equals 1Gi.",
+		Example:     "resources:\n  requests:\n    memory: 2Gi\n  limits:\n    memory: 1Gi",
+		Fix:         "Lower the request or raise the limit.",
+	},
+	{
+		ID: "POD027", Severity: SeverityError,
+		Summary:     "command, args, env or envFrom has invalid format",
+		Description: "command and args must be arrays of strings. env must be an array of objects, each with a string name and, optionally, a string value. envFrom must be an array of objects. Unquoted numbers and booleans are not strings.",
+		Example:     "args: --port=8080\nenv:\n  - name: PORT\n    value: 8080",
+		Fix:         "Use lists and quote the values: args: [\"--port=8080\"], value: \"8080\".",
+	},
+	{
+		ID: "POD031", Severity: SeverityError,
+		Summary:     "resource quantity uses a unit outside the allowed set",
+		Description: "With --quantity-units, or the units option of this rule in the project config, resource quantities may only use the listed suffixes. An empty entry allows plain numbers. Keeping to a few units makes values easy to compare across a code base.",
+		Example:     "resources:\n  limits:\n    memory: 1G",
+		Fix:         "Rewrite the value with an allowed unit, e.g. 1Gi.",
+	},
+	{
+		ID: "POD035", Severity: SeverityError,
+		Summary:     "container port name is invalid or repeated",
+		Description: "Port names are what probes and Services refer to instead of numbers. They must be IANA service names: at most 15 lowercase alphanumerics or '-', containing a letter, without leading, trailing or doubled '-'. Each name may appear once per container.",
+		Example:     "ports:\n  - name: http_metrics\n    containerPort: 9090",
+		Fix:         "Use a short name such as metrics.",
+	},
+	{
+		ID: "POD036", Severity: SeverityError,
+		Summary:     "env or envFrom entry is incomplete, ambiguous or repeated",
+		Description: "env names must be C identifiers (letters, digits and '_', not starting with a digit) and appear once per container, since a repeated name silently overrides the earlier one. An entry sets value or valueFrom but not both, and valueFrom uses exactly one source. Each envFrom entry references exactly one ConfigMap or Secret by a non-empty name.",
+		Example:     "env:\n  - name: LOG-LEVEL\n    value: debug\n    valueFrom:\n      configMapKeyRef: {name: app, key: level}",
+		Fix:         "Rename the variable, e.g. LOG_LEVEL, and keep only one source.",
+	},
+	{
+		ID: "POD042", Severity: SeverityError,
+		Summary:     "field not allowed for this kind of container",
+		Description: "Init containers run to completion before the pod starts, so they take no probes, unless they are sidecars with restartPolicy: Always, the only restartPolicy a container may set. Ephemeral containers are attached to a running pod for debugging and take no ports, probes, resources or lifecycle hooks.",
+		Example:     "initContainers:\n  - name: migrate\n    readinessProbe:\n      exec:\n        command: [true]",
+		Fix:         "Remove the field, or set restartPolicy: Always if the init container is meant as a sidecar.",
+	},
+}
+
+// validateContainers runs the per-container checks on one of the
+// containerLists.
+func (v *Validator) validateContainers(specNode *yaml.Node, list, filename string) []ValidationError {
+	var errs []ValidationError
+	conts := findMapKey(specNode, list)
+	// line of the first container with each name
+	names := make(map[string]int)
+	for _, contNode := range sequenceItems(conts) {
+		if contNode.Kind != yaml.MappingNode {
+			continue
+		}
+		if nameNode := findMapKey(contNode, "name"); nameNode != nil && nameNode.Kind == yaml.ScalarNode && nameNode.Value != "" {
+			if first, ok := names[nameNode.Value]; ok {
+				errs = append(errs, newFieldError(filename, nameNode, "name", "POD025", "duplicate container name '%s', first defined on line %d", nameNode.Value, first))
+			} else {
+				names[nameNode.Value] = nameNode.Line
+			}
+		}
+		errs = append(errs, validateContainerKind(contNode, list, filename)...)
+		errs = append(errs, validateImage(contNode, filename)...)
+		if len(v.AllowedRegistries) > 0 {
+			errs = append(errs, v.validateRegistry(contNode, filename)...)
+		}
+		errs = append(errs, v.validateTagPolicy(contNode, filename)...)
+		// probe handler port validation
+		errs = append(errs, validateProbes(contNode, filename)...)
+		// resources.requests.cpu validation
+		errs = append(errs, v.validateQuantities(contNode, filename)...)
+		errs = append(errs, v.validatePorts(contNode, filename)...)
+		errs = append(errs, v.validateResourceMaximums(contNode, filename)...)
+		errs = append(errs, validateRequestsWithinLimits(contNode, filename)...)
+		errs = append(errs, validateEnv(contNode, filename)...)
+		if v.Strict && list == "containers" {
+			errs = append(errs, validateProbePairing(contNode, filename)...)
+		}
+	}
+	return errs
+}
+
+// validateContainerKind reports fields the API server rejects on init and
+// ephemeral containers. Init containers run to completion before the pod
+// is ready, so probes make no sense there, except on sidecars, init
+// containers with restartPolicy Always. Ephemeral containers are attached
+// to a running pod for debugging and get no ports, probes or resources.
+func validateContainerKind(contNode *yaml.Node, list, filename string) []ValidationError {
+	var forbidden []string
+	switch list {
+	case "initContainers":
+		if scalarValue(contNode, "restartPolicy") != "Always" {
+			forbidden = probeTypes
+		}
+	case "ephemeralContainers":
+		forbidden = append([]string{"ports", "resources", "lifecycle"}, probeTypes...)
+	}
+	var errs []ValidationError
+	for _, field := range forbidden {
+		if keyNode := findMapKeyNode(contNode, field); keyNode != nil {
+			errs = append(errs, newFieldError(filename, keyNode, field, "POD042", "%s is not allowed in %s", field, list))
+		}
+	}
+	if rp := findMapKey(contNode, "restartPolicy"); rp != nil && (list != "initContainers" || rp.Value != "Always") {
+		errs = append(errs, newFieldError(filename, rp, "restartPolicy", "POD042", "restartPolicy is only allowed as Always in initContainers"))
+	}
+	return errs
+}
+
+// validateContainerNameOverlap reports containers that reuse the name of
+// one in another of the containerLists; names are unique across the pod.
+func validateContainerNameOverlap(specNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	// list and line of the first container with each name
+	type first struct {
+		list string
+		line int
+	}
+	names := make(map[string]first)
+	for _, list := range containerLists {
+		for _, contNode := range sequenceItems(findMapKey(specNode, list)) {
+			nameNode := findMapKey(contNode, "name")
+			if nameNode == nil || nameNode.Kind != yaml.ScalarNode || nameNode.Value == "" {
+				continue
+			}
+			f, ok := names[nameNode.Value]
+			if !ok {
+				names[nameNode.Value] = first{list, nameNode.Line}
+			} else if f.list != list {
+				errs = append(errs, newFieldError(filename, nameNode, "name", "POD020", "name '%s' used in both %s (line %d) and %s", nameNode.Value, f.list, f.line, list))
+			}
+		}
+	}
+	return errs
+}
+
+// validateEmptyDirLimits warns when an unbounded emptyDir is mounted by a
+// pod whose containers have no ephemeral-storage limit: a runaway writer
+// can then fill the node disk and get other pods evicted.
+func validateEmptyDirLimits(specNode *yaml.Node, filename string) []ValidationError {
+	vols := findMapKey(specNode, "volumes")
+	if vols == nil || vols.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var unbounded *yaml.Node
+	for _, vol := range vols.Content {
+		if ed := findMapKey(vol, "emptyDir"); ed != nil && findMapKey(ed, "sizeLimit") == nil {
+			unbounded = vol
+			break
+		}
+	}
+	if unbounded == nil {
+		return nil
+	}
+	if conts := findMapKey(specNode, "containers"); conts != nil && conts.Kind == yaml.SequenceNode {
+		for _, contNode := range conts.Content {
+			limits := findMapKey(findMapKey(contNode, "resources"), "limits")
+			if findMapKey(limits, "ephemeral-storage") == nil {
+				return []ValidationError{newFieldError(filename, unbounded, "emptyDir", "POD021", "pod uses emptyDir without ephemeral-storage limits")}
+			}
+		}
+	}
+	return nil
+}
+
+// validateCPUUnitConsistency notes pods whose containers write cpu both
+// as millicores (500m) and as whole cores (1). Not wrong, just harder to
+// compare at a glance.
+func validateCPUUnitConsistency(specNode *yaml.Node, filename string) []ValidationError {
+	conts := findMapKey(specNode, "containers")
+	if conts == nil || conts.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var first *yaml.Node
+	for _, contNode := range conts.Content {
+		resNode := findMapKey(contNode, "resources")
+		for _, resType := range []string{"limits", "requests"} {
+			cpuNode := findMapKey(findMapKey(resNode, resType), "cpu")
+			if cpuNode == nil || cpuNode.Kind != yaml.ScalarNode {
+				continue
+			}
+			if first == nil {
+				first = cpuNode
+			} else if strings.HasSuffix(first.Value, "m") != strings.HasSuffix(cpuNode.Value, "m") {
+				return []ValidationError{newFieldError(filename, cpuNode, "cpu", "POD022", "cpu value '%s' mixes units with '%s' at line %d", cpuNode.Value, first.Value, first.Line)}
+			}
+		}
+	}
+	return nil
+}
+
+// validatePortNameConsistency warns when one port name is bound to
+// different numbers in different containers. Services that target the
+// port by name then route to whichever container happens to match.
+func validatePortNameConsistency(specNode *yaml.Node, filename string) []ValidationError {
+	conts := findMapKey(specNode, "containers")
+	if conts == nil || conts.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var errs []ValidationError
+	numbers := make(map[string]string)
+	reported := make(map[string]bool)
+	for _, contNode := range conts.Content {
+		portsNode := findMapKey(contNode, "ports")
+		if portsNode == nil || portsNode.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, portEntry := range portsNode.Content {
+			nameNode := findMapKey(portEntry, "name")
+			cpNode := findMapKey(portEntry, "containerPort")
+			if nameNode == nil || nameNode.Kind != yaml.ScalarNode || nameNode.Value == "" || cpNode == nil || cpNode.Kind != yaml.ScalarNode {
+				continue
+			}
+			first, ok := numbers[nameNode.Value]
+			if !ok {
+				numbers[nameNode.Value] = cpNode.Value
+			} else if first != cpNode.Value && !reported[nameNode.Value] {
+				errs = append(errs, newFieldError(filename, nameNode, "name", "POD024", "port name '%s' maps to inconsistent numbers", nameNode.Value))
+				reported[nameNode.Value] = true
+			}
+		}
+	}
+	return errs
+}
+
+func (v *Validator) validateNamespace(mapping *yaml.Node, filename string) []ValidationError {
+	metaNode := findMapKey(mapping, "metadata")
+	if metaNode == nil || metaNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	nsNode := findMapKey(metaNode, "namespace")
+	if nsNode == nil || (nsNode.Kind == yaml.ScalarNode && nsNode.Value == "") {
+		if v.RequireNamespace {
+			return []ValidationError{newFieldError(filename, metaNode, "namespace", "DOC009", "namespace is required")}
+		}
+		if !contains(v.AllowedNamespaces, "default") {
+			return []ValidationError{newFieldError(filename, metaNode, "namespace", "DOC009", "namespace 'default' is not allowed")}
+		}
+		return nil
+	}
+	if nsNode.Kind == yaml.ScalarNode && !contains(v.AllowedNamespaces, nsNode.Value) {
+		return []ValidationError{newFieldError(filename, nsNode, "namespace", "DOC009", "namespace '%s' is not allowed", nsNode.Value)}
+	}
+	return nil
+}
+
+// validateMetadata reports blocks mis-indented under metadata (POD007).
+// The metadata fields themselves are checked against their schema.
+func validateMetadata(mapping *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	metaNode := findMapKey(mapping, "metadata")
+	if metaNode == nil || metaNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	// spec or containers nested under metadata is almost always a
+	// mis-indented block rather than an intentional field
+	for _, key := range []string{"spec", "containers"} {
+		if keyNode := findMapKeyNode(metaNode, key); keyNode != nil {
+			errs = append(errs, newFieldError(filename, keyNode, key, "POD007", "possible indentation error: '%s' found under metadata", key))
+		}
+	}
+	return errs
+}
+
+// isDNSLabel reports whether s is a DNS-1123 label: at most 63 lowercase
+// alphanumerics or '-', starting and ending with an alphanumeric.
+func isDNSLabel(s string) bool {
+	if len(s) == 0 || len(s) > 63 || s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// isDNSSubdomain reports whether s is a DNS-1123 subdomain: at most 253
+// characters of dot-separated DNS-1123 labels.
+func isDNSSubdomain(s string) bool {
+	if len(s) > 253 {
+		return false
+	}
+	for {
+		label, rest, more := strings.Cut(s, ".")
+		if !isDNSLabel(label) {
+			return false
+		}
+		if !more {
+			return true
+		}
+		s = rest
+	}
+}
+
+func findMapKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	// Mapping node Content has [key0, val0, key1, val1, ...]
+	for i := 0; i < len(node.Content); i += 2 {
+		k := node.Content[i]
+		if k.Kind == yaml.ScalarNode && k.Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// requiredScalar looks up a required field and reports it when it is
+// missing or set to an empty scalar. The node is returned only when it
+// passed those checks; type checks are left to the caller.
+func requiredScalar(parent *yaml.Node, key, field, rule, filename string) (*yaml.Node, []ValidationError) {
+	node := findMapKey(parent, key)
+	if node == nil {
+		return nil, []ValidationError{newFieldError(filename, parent, field, rule, "%s is required", field)}
+	}
+	if node.Kind == yaml.ScalarNode && node.Value == "" {
+		return nil, []ValidationError{newFieldError(filename, node, field, "POD014", "%s must not be empty", field)}
+	}
+	return node, nil
+}
+
+// checkIntRange reports a value that is not an int or falls outside
+// [min, max], echoing the offending value.
+func checkIntRange(node *yaml.Node, field string, min, max int, typeRule, rangeRule, filename string) []ValidationError {
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+		e := newFieldError(filename, node, field, typeRule, "%s must be int", field)
+		// A quoted number, e.g. "8080", only needs its quotes removed
+		if _, err := strconv.Atoi(node.Value); err == nil && node.Kind == yaml.ScalarNode && node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			e = e.withFix(node.Value)
+		}
+		return []ValidationError{e}
+	}
+	if n, err := strconv.Atoi(node.Value); err != nil || n < min || n > max {
+		return []ValidationError{newFieldError(filename, node, field, rangeRule, "%s value %s out of range (%d-%d)", field, node.Value, min, max)}
+	}
+	return nil
+}
+
+// scalarValue returns the value of a scalar mapping entry, or "" when the
+// key is missing or not a scalar.
+func scalarValue(node *yaml.Node, key string) string {
+	if n := findMapKey(node, key); n != nil && n.Kind == yaml.ScalarNode {
+		return n.Value
+	}
+	return ""
+}
+
+// findMapKeyNode returns the key node itself, which carries the line of
+// the key rather than of its value.
+func findMapKeyNode(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		k := node.Content[i]
+		if k.Kind == yaml.ScalarNode && k.Value == key {
+			return k
+		}
+	}
+	return nil
+}
+
+func validateOS(specNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	osNode := findMapKey(specNode, "os")
+	if osNode != nil {
+		if osNode.Kind == yaml.ScalarNode {
+			if osNode.Value != "linux" && osNode.Value != "windows" {
+				errs = append(errs, newFieldError(filename, osNode, "os", "POD001", "os has unsupported value '%s'", osNode.Value))
+			}
+		} else if osNode.Kind == yaml.MappingNode {
+			nameNode, reqErrs := requiredScalar(osNode, "name", "os.name", "POD002", filename)
+			errs = append(errs, reqErrs...)
+			if nameNode != nil {
+				if nameNode.Kind != yaml.ScalarNode {
+					errs = append(errs, newFieldError(filename, nameNode, "os.name", "POD003", "os.name must be string"))
+				} else if nameNode.Value != "linux" && nameNode.Value != "windows" {
+					errs = append(errs, newFieldError(filename, nameNode, "os.name", "POD001", "os has unsupported value '%s'", nameNode.Value))
+				}
+			}
+		} else {
+			errs = append(errs, newFieldError(filename, osNode, "os", "POD004", "os must be string or object"))
+		}
+	}
+	return errs
+}
+
+// probeTypes lists the probes validated on every container.
+var probeTypes = []string{"readinessProbe", "livenessProbe", "startupProbe"}
+
+// probeRules are reported by the probe checks.
+var probeRules = []Rule{
+	{
+		ID: "POD005", Severity: SeverityError,
+		Summary:     "probe port must be int in range",
+		Description: "A probe port must be a port number between 1 and 65535.",
+		Example:     "readinessProbe:\n  httpGet:\n    port: 70000",
+		Fix:         "Point the probe at the port the container actually listens on.",
+	},
+	{
+		ID: "POD017", Severity: SeverityWarning,
+		Summary:     "livenessProbe without readinessProbe (--strict)",
+		Description: "Without a readiness probe a container receives traffic as soon as it starts, even if it is not ready to serve. Only checked with --strict.",
+		Example:     "containers:\n  - name: web\n    livenessProbe: {...}",
+		Fix:         "Add a readinessProbe next to the livenessProbe.",
+	},
+	{
+		ID: "POD018", Severity: SeverityError,
+		Summary:     "probe port name does not resolve",
+		Description: "httpGet and tcpSocket probes may refer to a port by name, but the name must be declared in the container's ports list.",
+		Example:     "ports:\n  - containerPort: 8080\nreadinessProbe:\n  httpGet:\n    port: http",
+		Fix:         "Add name: http to the port entry or use the port number.",
+	},
+	{
+		ID: "POD023", Severity: SeverityError,
+		Summary:     "tcpSocket probe targets a non-TCP port",
+		Description: "A tcpSocket probe opens a TCP connection; pointing it at a port declared only for UDP or SCTP can never succeed.",
+		Example:     "ports:\n  - containerPort: 53\n    protocol: UDP\nlivenessProbe:\n  tcpSocket:\n    port: 53",
+		Fix:         "Probe a TCP port or use an exec probe.",
+	},
+	{
+		ID: "POD032", Severity: SeverityError,
+		Summary:     "probe timing field is not an int in range",
+		Description: "initialDelaySeconds (0-3600), periodSeconds and timeoutSeconds (1-3600), and successThreshold and failureThreshold (1-100) must be integers in range. The upper bounds are not enforced by the API server but catch values written in milliseconds. successThreshold must be 1 for liveness and startup probes.",
+		Example:     "livenessProbe:\n  periodSeconds: 10000",
+		Fix:         "Write the value in seconds, e.g. periodSeconds: 10.",
+	},
+	{
+		ID: "POD033", Severity: SeverityWarning,
+		Summary:     "probe timeout is not less than its period",
+		Description: "When timeoutSeconds is at least periodSeconds (10 unless set), a slow probe is still running when the next one is due, so failures are detected later than the settings suggest.",
+		Example:     "readinessProbe:\n  periodSeconds: 5\n  timeoutSeconds: 5",
+		Fix:         "Lower timeoutSeconds or raise periodSeconds.",
+	},
+	{
+		ID: "POD034", Severity: SeverityError,
+		Summary:     "probe handler is missing, repeated or incomplete",
+		Description: "A probe uses exactly one handler: exec, httpGet, tcpSocket or grpc. exec needs a non-empty command array of strings; the others need a port.",
+		Example:     "livenessProbe:\n  exec:\n    command: cat /tmp/healthy\n  tcpSocket:\n    port: 8080",
+		Fix:         "Keep one handler and write exec commands as arrays, e.g. command: [cat, /tmp/healthy].",
+	},
+	{
+		ID: "POD043", Severity: SeverityWarning,
+		Summary:     "probe httpGet.path does not start with '/'",
+		Description: "The path of an httpGet probe is an absolute URL path. The kubelet adds a missing leading '/', but tools that reuse probe paths, such as service meshes rewriting probes, may not, and the path reads like a relative one.",
+		Example:     "readinessProbe:\n  httpGet:\n    path: healthz\n    port: 8080",
+		Fix:         "Start the path with '/': path: /healthz. yamlvalid fix does this for you.",
+	},
+}
+
+func validateProbes(contNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	for _, probe := range probeTypes {
+		probeNode := findMapKey(contNode, probe)
+		if probeNode == nil || probeNode.Kind != yaml.MappingNode {
+			continue
+		}
+		errs = append(errs, validateProbeHandler(probe, probeNode, filename)...)
+		errs = append(errs, validateProbePorts(probeNode, contNode, filename)...)
+		errs = append(errs, validateProbeTiming(probe, probeNode, filename)...)
+	}
+	return errs
+}
+
+// probeHandlers are the ways a probe can check a container; a probe
+// uses exactly one of them.
+var probeHandlers = []string{"exec", "httpGet", "tcpSocket", "grpc"}
+
+// validateProbeHandler checks that a probe has exactly one handler and
+// that the handler carries what it needs: a command for exec, a port for
+// the others.
+func validateProbeHandler(probe string, probeNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	var handler string
+	var handlerNode *yaml.Node
+	for _, h := range probeHandlers {
+		node := findMapKey(probeNode, h)
+		if node == nil {
+			continue
+		}
+		if handlerNode != nil {
+			errs = append(errs, newFieldError(filename, findMapKeyNode(probeNode, h), h, "POD034", "%s has both %s and %s, only one handler is allowed", probe, handler, h))
+			continue
+		}
+		handler, handlerNode = h, node
+	}
+	if handlerNode == nil {
+		return []ValidationError{newFieldError(filename, probeNode, probe, "POD034", "%s must have one of %s", probe, strings.Join(probeHandlers, ", "))}
+	}
+	if handlerNode.Kind != yaml.MappingNode {
+		// schemas.yaml checks the type of exec and its command, taking a
+		// null exec for a missing one
+		if handler != "exec" || handlerNode.Tag == "!!null" {
+			errs = append(errs, newFieldError(filename, handlerNode, handler, "POD034", "%s must be object", handler))
+		}
+		return errs
+	}
+	if handler != "exec" {
+		if findMapKey(handlerNode, "port") == nil {
+			errs = append(errs, newFieldError(filename, handlerNode, "port", "POD034", "%s.port is required", handler))
+		}
+		if pathNode := findMapKey(handlerNode, "path"); handler == "httpGet" && pathNode != nil && isString(pathNode) && pathNode.Value != "" && !strings.HasPrefix(pathNode.Value, "/") {
+			errs = append(errs, newFieldError(filename, pathNode, "path", "POD043", "httpGet.path must start with '/', got '%s'", pathNode.Value).withValueFix(pathNode, "/"+pathNode.Value))
+		}
+		return errs
+	}
+	cmdNode := findMapKey(handlerNode, "command")
+	switch {
+	case cmdNode == nil:
+		errs = append(errs, newFieldError(filename, handlerNode, "command", "POD034", "exec.command is required"))
+	case cmdNode.Kind == yaml.SequenceNode && len(cmdNode.Content) == 0:
+		errs = append(errs, newFieldError(filename, cmdNode, "command", "POD034", "exec.command must not be empty"))
+	}
+	return errs
+}
+
+// probeTimingLimits bounds the timing fields of a probe. The API server
+// only enforces the minimums; the maximums catch values written in the
+// wrong unit, such as milliseconds.
+var probeTimingLimits = []struct {
+	field    string
+	min, max int
+}{
+	{"initialDelaySeconds", 0, 3600},
+	{"periodSeconds", 1, 3600},
+	{"timeoutSeconds", 1, 3600},
+	{"successThreshold", 1, 100},
+	{"failureThreshold", 1, 100},
+}
+
+// validateProbeTiming checks the timing fields of a probe and warns when
+// a probe may still be running when the next one starts.
+func validateProbeTiming(probe string, probeNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	valid := true
+	for _, l := range probeTimingLimits {
+		if node := findMapKey(probeNode, l.field); node != nil {
+			if rangeErrs := checkIntRange(node, l.field, l.min, l.max, "POD032", "POD032", filename); rangeErrs != nil {
+				errs = append(errs, rangeErrs...)
+				valid = false
+			}
+		}
+	}
+	if !valid {
+		return errs
+	}
+	// Only readiness probes may require more than one success in a row
+	if node := findMapKey(probeNode, "successThreshold"); node != nil && probe != "readinessProbe" && node.Value != "1" {
+		errs = append(errs, newFieldError(filename, node, "successThreshold", "POD032", "successThreshold must be 1 for %s", probe))
+	}
+	// Kubernetes defaults
+	period, timeout := 10, 1
+	if node := findMapKey(probeNode, "periodSeconds"); node != nil {
+		period, _ = strconv.Atoi(node.Value)
+	}
+	if node := findMapKey(probeNode, "timeoutSeconds"); node != nil {
+		timeout, _ = strconv.Atoi(node.Value)
+		if timeout >= period {
+			errs = append(errs, newFieldError(filename, node, "timeoutSeconds", "POD033", "timeoutSeconds %d is not less than periodSeconds %d", timeout, period))
+		}
+	}
+	return errs
+}
+
+// validateProbePorts checks the port of each probe handler. httpGet and
+// tcpSocket take a number or the name of a container port, grpc only a
+// number, and exec has no port at all.
+func validateProbePorts(probeNode, contNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	for _, handler := range []string{"httpGet", "tcpSocket", "grpc"} {
+		handlerNode := findMapKey(probeNode, handler)
+		if handlerNode == nil || handlerNode.Kind != yaml.MappingNode {
+			continue
+		}
+		portNode := findMapKey(handlerNode, "port")
+		if portNode == nil || portNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		if portNode.Tag == "!!str" && handler != "grpc" {
+			if !hasNamedPort(contNode, portNode.Value) {
+				errs = append(errs, newFieldError(filename, portNode, "port", "POD018", "port '%s' does not match any named container port", portNode.Value))
+				continue
+			}
+		} else if rangeErrs := checkIntRange(portNode, "port", 1, 65535, "POD005", "POD005", filename); rangeErrs != nil {
+			errs = append(errs, rangeErrs...)
+			continue
+		}
+		if handler == "tcpSocket" {
+			if protos := declaredProtocols(contNode, portNode); len(protos) > 0 && !contains(protos, "TCP") {
+				errs = append(errs, newFieldError(filename, portNode, "port", "POD023", "tcpSocket probe targets a %s-only port", protos[0]))
+			}
+		}
+	}
+	return errs
+}
+
+// declaredProtocols returns the protocols of the container ports a probe
+// port refers to, by number or by name. A missing protocol means TCP.
+func declaredProtocols(contNode, portNode *yaml.Node) []string {
+	var protos []string
+	portsNode := findMapKey(contNode, "ports")
+	if portsNode == nil || portsNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, p := range portsNode.Content {
+		if scalarValue(p, "containerPort") != portNode.Value && scalarValue(p, "name") != portNode.Value {
+			continue
+		}
+		proto := scalarValue(p, "protocol")
+		if proto == "" {
+			proto = "TCP"
+		}
+		protos = append(protos, proto)
+	}
+	return protos
+}
+
+// hasNamedPort reports whether the container declares a port with the
+// given name.
+func hasNamedPort(contNode *yaml.Node, name string) bool {
+	if portsNode := findMapKey(contNode, "ports"); portsNode != nil && portsNode.Kind == yaml.SequenceNode {
+		for _, p := range portsNode.Content {
+			if scalarValue(p, "name") == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var supportedProtocols = []string{"TCP", "UDP", "SCTP"}
+
+func (v *Validator) validatePorts(contNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	portsNode := findMapKey(contNode, "ports")
+	if portsNode == nil {
+		return nil
+	}
+	if portsNode.Kind != yaml.SequenceNode {
+		return []ValidationError{newFieldError(filename, portsNode, "ports", "POD010", "ports must be array")}
+	}
+	// line of the first entry with each containerPort/protocol pair, and
+	// with each port name
+	seen := make(map[string]int)
+	names := make(map[string]int)
+	for _, portEntry := range portsNode.Content {
+		if portEntry.Kind != yaml.MappingNode {
+			errs = append(errs, newFieldError(filename, portEntry, "ports", "POD010", "ports entry must be object"))
+			continue
+		}
+		cpNode, reqErrs := requiredScalar(portEntry, "containerPort", "containerPort", "POD011", filename)
+		errs = append(errs, reqErrs...)
+		if cpNode != nil {
+			errs = append(errs, checkIntRange(cpNode, "containerPort", 1, 65535, "POD011", "POD012", filename)...)
+		}
+		if hpNode := findMapKey(portEntry, "hostPort"); hpNode != nil {
+			errs = append(errs, checkIntRange(hpNode, "hostPort", 1, 65535, "POD011", "POD012", filename)...)
+		}
+
+		// The type of protocol and name is checked against the schema
+		protoNode := findMapKey(portEntry, "protocol")
+		if protoNode != nil && isString(protoNode) && protoNode.Value != "" {
+			if !contains(supportedProtocols, protoNode.Value) {
+				upper := strings.ToUpper(protoNode.Value)
+				if !contains(supportedProtocols, upper) {
+					errs = append(errs, newFieldError(filename, protoNode, "protocol", "POD013", "protocol has unsupported value '%s'", protoNode.Value))
+				} else if v.Lenient {
+					protoNode.Value = upper
+				} else {
+					errs = append(errs, newFieldError(filename, protoNode, "protocol", "POD013", "protocol must be uppercase, got '%s'", protoNode.Value).withValueFix(protoNode, upper))
+				}
+			}
+		}
+
+		// A missing protocol defaults to TCP, so {containerPort: 80} and
+		// {containerPort: 80, protocol: TCP} collide
+		if cpNode != nil && cpNode.Kind == yaml.ScalarNode {
+			proto := "TCP"
+			if protoNode != nil && protoNode.Kind == yaml.ScalarNode && protoNode.Value != "" {
+				proto = protoNode.Value
+			}
+			key := cpNode.Value + "/" + proto
+			if first, ok := seen[key]; ok {
+				errs = append(errs, newFieldError(filename, cpNode, "containerPort", "POD015", "duplicate containerPort %s, first defined on line %d", key, first))
+			} else {
+				seen[key] = cpNode.Line
+			}
+		}
+
+		if nameNode := findMapKey(portEntry, "name"); nameNode != nil && isString(nameNode) {
+			switch {
+			case !isPortName(nameNode.Value):
+				errs = append(errs, newFieldError(filename, nameNode, "name", "POD035", "port name has invalid format '%s'", nameNode.Value))
+			default:
+				if first, ok := names[nameNode.Value]; ok {
+					errs = append(errs, newFieldError(filename, nameNode, "name", "POD035", "duplicate port name '%s', first defined on line %d", nameNode.Value, first))
+				} else {
+					names[nameNode.Value] = nameNode.Line
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// isPortName reports whether s is an IANA service name, the format of
+// container port names: at most 15 lowercase alphanumerics or '-', with
+// at least one letter, no leading, trailing or doubled '-'.
+func isPortName(s string) bool {
+	if len(s) == 0 || len(s) > 15 || s[0] == '-' || s[len(s)-1] == '-' || strings.Contains(s, "--") {
+		return false
+	}
+	letter := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			letter = true
+		case isDigit(c) || c == '-':
+		default:
+			return false
+		}
+	}
+	return letter
+}
+
+// validateQuantities checks that every resource request and limit is a
+// non-negative quantity and, when QuantityUnits is set, that it uses one
+// of the allowed suffixes.
+func (v *Validator) validateQuantities(contNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	resNode := findMapKey(contNode, "resources")
+	for _, resType := range []string{"limits", "requests"} {
+		section := findMapKey(resNode, resType)
+		if section == nil || section.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(section.Content); i += 2 {
+			name, valNode := section.Content[i].Value, section.Content[i+1]
+			if valNode.Kind != yaml.ScalarNode {
+				errs = append(errs, newFieldError(filename, valNode, name, "POD006", "%s must be quantity", name))
+				continue
+			}
+			q, suffix, ok := ParseQuantity(valNode.Value)
+			switch {
+			case !ok:
+				e := newFieldError(filename, valNode, name, "POD006", "%s has invalid quantity '%s'", name, valNode.Value)
+				if fixed := normalizeQuantity(valNode.Value); fixed != "" {
+					e = e.withValueFix(valNode, fixed)
+				}
+				errs = append(errs, e)
+			case q.Sign() < 0:
+				errs = append(errs, newFieldError(filename, valNode, name, "POD006", "%s must not be negative", name))
+			case len(v.QuantityUnits) > 0 && !contains(v.QuantityUnits, suffix):
+				unit := "unit '" + suffix + "'"
+				if suffix == "" {
+					unit = "no unit"
+				}
+				errs = append(errs, newFieldError(filename, valNode, name, "POD031", "%s value '%s' has %s, allowed: %s", name, valNode.Value, unit, strings.Join(v.QuantityUnits, ", ")))
+			}
+		}
+	}
+	return errs
+}
+
+// validateGracePeriod warns when the pod is killed sooner than its probes
+// could declare it unhealthy (periodSeconds * failureThreshold).
+func validateGracePeriod(specNode *yaml.Node, filename string) []ValidationError {
+	graceNode := findMapKey(specNode, "terminationGracePeriodSeconds")
+	if graceNode == nil || graceNode.Kind != yaml.ScalarNode {
+		return nil
+	}
+	grace, err := strconv.Atoi(graceNode.Value)
+	if err != nil {
+		return nil
+	}
+	conts := findMapKey(specNode, "containers")
+	if conts == nil || conts.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, contNode := range conts.Content {
+		for _, probe := range []string{"livenessProbe", "readinessProbe"} {
+			probeNode := findMapKey(contNode, probe)
+			if probeNode == nil || probeNode.Kind != yaml.MappingNode {
+				continue
+			}
+			// Kubernetes defaults
+			period, failures := 10, 3
+			if n := findMapKey(probeNode, "periodSeconds"); n != nil {
+				if v, err := strconv.Atoi(n.Value); err == nil {
+					period = v
+				}
+			}
+			if n := findMapKey(probeNode, "failureThreshold"); n != nil {
+				if v, err := strconv.Atoi(n.Value); err == nil {
+					failures = v
+				}
+			}
+			if grace < period*failures {
+				return []ValidationError{newFieldError(filename, graceNode, "terminationGracePeriodSeconds", "POD009", "terminationGracePeriodSeconds may be too short for probe timing")}
+			}
+		}
+	}
+	return nil
+}
+
+// validateResourceMaximums warns about implausibly large cpu and memory
+// values, which usually mean a units mistake like 8000Gi instead of 8Gi.
+func (v *Validator) validateResourceMaximums(contNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	resNode := findMapKey(contNode, "resources")
+	for _, resType := range []string{"limits", "requests"} {
+		section := findMapKey(resNode, resType)
+		if cpuNode := findMapKey(section, "cpu"); v.MaxCPU > 0 && cpuNode != nil && cpuNode.Kind == yaml.ScalarNode {
+			if cpu, _, ok := ParseQuantity(cpuNode.Value); ok && cpu.Cmp(big.NewRat(int64(v.MaxCPU), 1)) > 0 {
+				errs = append(errs, newFieldError(filename, cpuNode, "cpu", "POD016", "cpu value %s exceeds sane maximum %d", cpuNode.Value, v.MaxCPU))
+			}
+		}
+		if memNode := findMapKey(section, "memory"); v.MaxMemory > 0 && memNode != nil && memNode.Kind == yaml.ScalarNode {
+			if mem, ok := ParseMemory(memNode.Value); ok && mem > v.MaxMemory {
+				errs = append(errs, newFieldError(filename, memNode, "memory", "POD016", "memory value %s exceeds sane maximum", memNode.Value))
+			}
+		}
+	}
+	return errs
+}
+
+// envSources are the fields of env[].valueFrom, of which exactly one is
+// set.
+var envSources = []string{"configMapKeyRef", "secretKeyRef", "fieldRef", "resourceFieldRef"}
+
+// validateEnv checks env and envFrom entries for what the API server
+// would reject or silently override (POD036): names that are not C
+// identifiers, entries with both or neither of value and valueFrom, and
+// names set twice, where the last one wins. Their format (POD027) is
+// checked against the schema.
+func validateEnv(contNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	// line of the first entry with each name
+	names := make(map[string]int)
+	for _, entry := range sequenceItems(findMapKey(contNode, "env")) {
+		if entry.Kind != yaml.MappingNode {
+			continue
+		}
+		nameNode := findMapKey(entry, "name")
+		switch {
+		case nameNode == nil || !isString(nameNode) || nameNode.Value == "":
+		case !isCIdentifier(nameNode.Value):
+			errs = append(errs, newFieldError(filename, nameNode, "env entry name", "POD036", "env name has invalid format '%s'", nameNode.Value))
+		default:
+			if first, ok := names[nameNode.Value]; ok {
+				errs = append(errs, newFieldError(filename, nameNode, "env entry name", "POD036", "duplicate env name '%s', first defined on line %d", nameNode.Value, first))
+			} else {
+				names[nameNode.Value] = nameNode.Line
+			}
+		}
+		valueNode, fromNode := findMapKey(entry, "value"), findMapKey(entry, "valueFrom")
+		switch {
+		case valueNode != nil && fromNode != nil:
+			errs = append(errs, newFieldError(filename, findMapKeyNode(entry, "valueFrom"), "valueFrom", "POD036", "env entry has both value and valueFrom"))
+		case fromNode != nil:
+			errs = append(errs, oneOf(fromNode, "valueFrom", envSources, filename)...)
+		}
+	}
+
+	for _, entry := range sequenceItems(findMapKey(contNode, "envFrom")) {
+		if entry.Kind != yaml.MappingNode {
+			continue
+		}
+		refErrs := oneOf(entry, "envFrom entry", []string{"configMapRef", "secretRef"}, filename)
+		errs = append(errs, refErrs...)
+		if refErrs != nil {
+			continue
+		}
+		for _, ref := range []string{"configMapRef", "secretRef"} {
+			refNode := findMapKey(entry, ref)
+			if refNode == nil {
+				continue
+			}
+			if nameNode := findMapKey(refNode, "name"); nameNode == nil || nameNode.Kind != yaml.ScalarNode || nameNode.Value == "" {
+				errs = append(errs, newFieldError(filename, refNode, ref, "POD036", "%s.name is required", ref))
+			}
+		}
+	}
+	return errs
+}
+
+// oneOf reports a mapping that does not set exactly one of keys.
+func oneOf(node *yaml.Node, field string, keys []string, filename string) []ValidationError {
+	if node.Kind != yaml.MappingNode {
+		return []ValidationError{newFieldError(filename, node, field, "POD027", "%s must be object", field)}
+	}
+	var set []string
+	for _, k := range keys {
+		if findMapKey(node, k) != nil {
+			set = append(set, k)
+		}
+	}
+	switch len(set) {
+	case 0:
+		return []ValidationError{newFieldError(filename, node, field, "POD036", "%s must have one of %s", field, strings.Join(keys, ", "))}
+	case 1:
+		return nil
+	}
+	return []ValidationError{newFieldError(filename, findMapKeyNode(node, set[1]), field, "POD036", "%s has both %s and %s, only one is allowed", field, set[0], set[1])}
+}
+
+// sequenceItems returns the items of a sequence node, or nil for
+// anything else, so callers can range over optional lists.
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
+
+// isCIdentifier reports whether s is a letter or '_' followed by letters,
+// digits or '_', the names shells and most programs accept for
+// environment variables.
+func isCIdentifier(s string) bool {
+	if s == "" || isDigit(s[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !isAlnum(c) && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// isString reports whether node is a scalar that YAML resolves to a
+// string, i.e. not a bare number, boolean or null.
+func isString(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!str"
+}
+
+// validateRequestsWithinLimits reports requests above the matching limit.
+// Values that do not parse are left to the checks that already report
+// them.
+func validateRequestsWithinLimits(contNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	resNode := findMapKey(contNode, "resources")
+	requests, limits := findMapKey(resNode, "requests"), findMapKey(resNode, "limits")
+	for _, res := range []string{"cpu", "memory"} {
+		reqNode, limNode := findMapKey(requests, res), findMapKey(limits, res)
+		if reqNode == nil || limNode == nil || reqNode.Kind != yaml.ScalarNode || limNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		req, _, ok1 := ParseQuantity(reqNode.Value)
+		lim, _, ok2 := ParseQuantity(limNode.Value)
+		if ok1 && ok2 && req.Cmp(lim) > 0 {
+			errs = append(errs, newFieldError(filename, reqNode, "requests."+res, "POD026", "requests.%s %s exceeds limits.%s %s", res, reqNode.Value, res, limNode.Value))
+		}
+	}
+	return errs
+}
+
+// validateProbePairing flags a liveness probe without a readiness probe:
+// traffic can then reach a container that is alive but not ready.
+func validateProbePairing(contNode *yaml.Node, filename string) []ValidationError {
+	if findMapKey(contNode, "livenessProbe") != nil && findMapKey(contNode, "readinessProbe") == nil {
+		return []ValidationError{newFieldError(filename, findMapKeyNode(contNode, "livenessProbe"), "readinessProbe", "POD017", "container has livenessProbe but no readinessProbe")}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// podWith returns a Pod whose only container has the given fields
+// besides name and image, indented by six spaces.
+func podWith(container string) string {
+	return "apiVersion: v1\nkind: Pod\nmetadata:\n  name: test\nspec:\n  containers:\n    - name: app\n      image: nginx:1.25\n" + container
+}
+
+// ruleFindings validates src with the default settings and returns the
+// findings of rule.
+func ruleFindings(t *testing.T, src, rule string) []ValidationError {
+	t.Helper()
+	var out []ValidationError
+	for _, e := range (&Validator{}).ValidateBytes([]byte(src), "test.yaml") {
+		if e.Rule == rule {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestDuplicateContainerPorts(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		ports string
+		want  int
+	}{
+		{"missing protocol means TCP", "- containerPort: 80\n        - containerPort: 80\n          protocol: TCP", 1},
+		{"both implicit", "- containerPort: 80\n        - containerPort: 80", 1},
+		{"UDP on the same port", "- containerPort: 80\n        - containerPort: 80\n          protocol: UDP", 0},
+		{"TCP and UDP after implicit TCP", "- containerPort: 53\n        - containerPort: 53\n          protocol: UDP\n        - containerPort: 53\n          protocol: TCP", 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := ruleFindings(t, podWith("      ports:\n        "+tc.ports+"\n"), "POD015")
+			if len(errs) != tc.want {
+				t.Fatalf("got %d POD015 findings, want %d: %v", len(errs), tc.want, errs)
+			}
+		})
+	}
+}
+
+func TestNonObjectDocuments(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"list", "- apiVersion: v1\n- kind: Pod\n", []string{"document must be object"}},
+		{"bare scalar", "just a string\n", []string{"document must be object"}},
+		{"empty", "", nil},
+		{"empty between separators", "---\n---\n", nil},
+		{"comment only", "# nothing here\n", nil},
+		{"scalar after an empty document", "---\n---\n42\n", []string{"document must be object"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := (&Validator{}).ValidateBytes([]byte(tc.src), "test.yaml")
+			var got []string
+			for _, e := range errs {
+				if e.Rule != "DOC005" {
+					t.Errorf("unexpected finding: %s %s", e.Rule, e.Message)
+					continue
+				}
+				got = append(got, e.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Fatalf("got DOC005 findings %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// BenchmarkValidateValid validates the corpus in testdata/valid, which
+// has no findings: the common case of a CI run over a healthy repo.
+func BenchmarkValidateValid(b *testing.B) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "valid", "*.yaml"))
+	if err != nil || len(paths) == 0 {
+		b.Fatalf("no corpus: %v", err)
+	}
+	files := make([][]byte, len(paths))
+	size := 0
+	for i, path := range paths {
+		if files[i], err = os.ReadFile(path); err != nil {
+			b.Fatal(err)
+		}
+		size += len(files[i])
+	}
+	v := &Validator{}
+	b.ReportAllocs()
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, data := range files {
+			if errs := v.ValidateBytes(data, paths[j]); len(errs) > 0 {
+				b.Fatalf("%s: unexpected finding: %s", paths[j], errs[0].Text())
+			}
+		}
+	}
+}
+
+// probePorts has a container per handler type, each with a probe naming
+// an undeclared port and one naming the declared port http.
+const probePorts = `apiVersion: v1
+kind: Pod
+metadata:
+  name: test
+spec:
+  containers:
+    - name: http-get
+      image: nginx:1.25
+      ports: [{name: http, containerPort: 8080}]
+      livenessProbe:
+        httpGet: {path: /, port: web}
+      readinessProbe:
+        httpGet: {path: /, port: http}
+    - name: tcp-socket
+      image: nginx:1.25
+      ports: [{name: http, containerPort: 8080}]
+      livenessProbe:
+        tcpSocket: {port: web}
+      readinessProbe:
+        tcpSocket: {port: http}
+    - name: exec-and-grpc
+      image: nginx:1.25
+      ports: [{name: http, containerPort: 8080}]
+      livenessProbe:
+        exec: {command: [/check]}
+      readinessProbe:
+        grpc: {port: 9000}
+`
+
+func TestProbeNamedPorts(t *testing.T) {
+	var got []int
+	for _, e := range ruleFindings(t, probePorts, "POD018") {
+		got = append(got, e.Line)
+	}
+	// The undeclared web of httpGet and tcpSocket; exec has no port and
+	// grpc takes only numbers
+	if want := []int{11, 18}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got POD018 findings on lines %v, want %v", got, want)
+	}
+}
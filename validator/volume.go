@@ -0,0 +1,118 @@
+package validator
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// volumeRules are reported by validateVolumes and validateVolumeMounts.
+var volumeRules = []Rule{
+	{
+		ID: "POD037", Severity: SeverityError,
+		Summary:     "volume is unnamed, repeated or has no single source",
+		Description: "Each entry of spec.volumes needs a unique DNS-1123 label as name and exactly one volume source, such as emptyDir, configMap, secret or persistentVolumeClaim.",
+		Example:     "volumes:\n  - name: data\n    emptyDir: {}\n    persistentVolumeClaim:\n      claimName: data",
+		Fix:         "Keep one source per volume and give each volume its own name.",
+	},
+	{
+		ID: "POD038", Severity: SeverityError,
+		Summary:     "volumeMount is incomplete or does not match a volume",
+		Description: "Each volumeMount of a container, init container or ephemeral container needs the name of a volume declared in spec.volumes and an absolute mountPath. Two mounts of one container cannot share a path.",
+		Example:     "volumeMounts:\n  - name: cache\n    mountPath: tmp/cache",
+		Fix:         "Declare the volume, fix the name, and start mountPath with '/'.",
+	},
+}
+
+// validateVolumes checks spec.volumes (POD037) and the volumeMounts of
+// every container against them (POD038).
+func validateVolumes(specNode *yaml.Node, filename string) []ValidationError {
+	var errs []ValidationError
+	volsNode := findMapKey(specNode, "volumes")
+	volsOK := volsNode == nil || volsNode.Kind == yaml.SequenceNode
+	if !volsOK {
+		errs = append(errs, newFieldError(filename, volsNode, "volumes", "POD037", "volumes must be array"))
+	}
+	// line of each declared volume name
+	declared := make(map[string]int)
+	for _, vol := range sequenceItems(volsNode) {
+		if vol.Kind != yaml.MappingNode {
+			errs = append(errs, newFieldError(filename, vol, "volumes", "POD037", "volumes entry must be object"))
+			continue
+		}
+		nameNode, reqErrs := requiredScalar(vol, "name", "volume name", "POD037", filename)
+		errs = append(errs, reqErrs...)
+		if nameNode != nil {
+			if !isDNSLabel(nameNode.Value) {
+				errs = append(errs, newFieldError(filename, nameNode, "name", "POD037", "volume name has invalid format '%s'", nameNode.Value))
+			}
+			if first, ok := declared[nameNode.Value]; ok {
+				errs = append(errs, newFieldError(filename, nameNode, "name", "POD037", "duplicate volume name '%s', first defined on line %d", nameNode.Value, first))
+			} else {
+				declared[nameNode.Value] = nameNode.Line
+			}
+		}
+		// Every key besides name is a volume source
+		var sources []string
+		for i := 0; i+1 < len(vol.Content); i += 2 {
+			if k := vol.Content[i].Value; k != "name" {
+				sources = append(sources, k)
+			}
+		}
+		switch {
+		case len(sources) == 0:
+			errs = append(errs, newFieldError(filename, vol, "volumes", "POD037", "volume must have a source such as emptyDir, configMap or persistentVolumeClaim"))
+		case len(sources) > 1:
+			errs = append(errs, newFieldError(filename, findMapKeyNode(vol, sources[1]), sources[1], "POD037", "volume has both %s and %s, only one source is allowed", sources[0], sources[1]))
+		}
+	}
+
+	for _, list := range containerLists {
+		for _, contNode := range sequenceItems(findMapKey(specNode, list)) {
+			errs = append(errs, validateVolumeMounts(contNode, declared, volsOK, filename)...)
+		}
+	}
+	return errs
+}
+
+// validateVolumeMounts checks the volumeMounts of one container. Mounts
+// are only matched against declared volumes when spec.volumes could be
+// read, so a broken volumes list does not flag every mount as well.
+func validateVolumeMounts(contNode *yaml.Node, declared map[string]int, checkNames bool, filename string) []ValidationError {
+	mountsNode := findMapKey(contNode, "volumeMounts")
+	if mountsNode == nil {
+		return nil
+	}
+	if mountsNode.Kind != yaml.SequenceNode {
+		return []ValidationError{newFieldError(filename, mountsNode, "volumeMounts", "POD038", "volumeMounts must be array")}
+	}
+	var errs []ValidationError
+	// line of the first mount at each path
+	paths := make(map[string]int)
+	for _, mount := range mountsNode.Content {
+		if mount.Kind != yaml.MappingNode {
+			errs = append(errs, newFieldError(filename, mount, "volumeMounts", "POD038", "volumeMounts entry must be object"))
+			continue
+		}
+		nameNode, reqErrs := requiredScalar(mount, "name", "volumeMount name", "POD038", filename)
+		errs = append(errs, reqErrs...)
+		if nameNode != nil && checkNames {
+			if _, ok := declared[nameNode.Value]; !ok {
+				errs = append(errs, newFieldError(filename, nameNode, "name", "POD038", "volumeMount '%s' does not match any volume", nameNode.Value))
+			}
+		}
+		pathNode, reqErrs := requiredScalar(mount, "mountPath", "mountPath", "POD038", filename)
+		errs = append(errs, reqErrs...)
+		if pathNode == nil {
+			continue
+		}
+		if !strings.HasPrefix(pathNode.Value, "/") {
+			errs = append(errs, newFieldError(filename, pathNode, "mountPath", "POD038", "mountPath must be absolute, got '%s'", pathNode.Value))
+		} else if first, ok := paths[pathNode.Value]; ok {
+			errs = append(errs, newFieldError(filename, pathNode, "mountPath", "POD038", "duplicate mountPath '%s', first defined on line %d", pathNode.Value, first))
+		} else {
+			paths[pathNode.Value] = pathNode.Line
+		}
+	}
+	return errs
+}
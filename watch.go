@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"go-test-maga/validator"
+)
+
+// watchDebounce lets a burst of events, such as an editor writing a
+// temporary file and renaming it over the original, settle into one run.
+const watchDebounce = 100 * time.Millisecond
+
+// watchPaths validates paths, then again each YAML file below them that
+// changes, until interrupted. report prints the findings of every run;
+// after it a summary line covers all files known so far, so the last
+// line always tells whether the tree is clean.
+func watchPaths(v *validator.Validator, paths []string, report func(validator.Result)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	// Files named on the command line are watched through their
+	// directory, since editors often replace a file rather than write it
+	files := make(map[string]bool)
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		fi, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			err = watchTree(w, p)
+		} else {
+			files[p] = true
+			err = w.Add(filepath.Dir(p))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	known := make(map[string]validator.FileResult)
+	run := func(paths ...string) {
+		res := v.ValidatePaths(paths...)
+		for _, r := range res.Files {
+			known[filepath.Clean(r.File)] = r
+		}
+		report(res)
+		writeRunSummary(os.Stderr, combineResults(known))
+	}
+	run(paths...)
+
+	pending := make(map[string]bool)
+	removed := false
+	var settle <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			name := filepath.Clean(ev.Name)
+			if ev.Has(fsnotify.Create) && isWatchedDir(name) && underDir(name, paths) {
+				// A new directory is watched and its files validated
+				watchTree(w, name)
+				pending[name] = true
+			} else if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+				for f := range known {
+					if f == name || strings.HasPrefix(f, name+string(filepath.Separator)) {
+						delete(known, f)
+						removed = true
+					}
+				}
+				delete(pending, name)
+			} else if isYAMLName(name) && (files[name] || underDir(name, paths)) {
+				pending[name] = true
+			}
+			settle = time.After(watchDebounce)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Error watching files: %v\n", err)
+		case <-settle:
+			settle = nil
+			var changed []string
+			for p := range pending {
+				// Files of a new directory are validated with it
+				if _, err := os.Stat(p); err == nil && !pending[filepath.Dir(p)] {
+					changed = append(changed, p)
+				}
+			}
+			pending = make(map[string]bool)
+			sort.Strings(changed)
+			switch {
+			case len(changed) > 0:
+				fmt.Fprintf(os.Stderr, "\n[%s] %s changed\n", time.Now().Format("15:04:05"), strings.Join(changed, ", "))
+				run(changed...)
+			case removed:
+				fmt.Fprintf(os.Stderr, "\n[%s] files removed\n", time.Now().Format("15:04:05"))
+				writeRunSummary(os.Stderr, combineResults(known))
+			}
+			removed = false
+		}
+	}
+}
+
+// watchTree watches dir and every directory below it, skipping hidden
+// ones like validateDir does.
+func watchTree(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.Add(path)
+	})
+}
+
+func isWatchedDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir() && !strings.HasPrefix(filepath.Base(path), ".")
+}
+
+func isYAMLName(path string) bool {
+	return strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")
+}
+
+// underDir reports whether path is below one of the directories in paths.
+func underDir(path string, paths []string) bool {
+	for _, p := range paths {
+		if fi, err := os.Stat(p); err != nil || !fi.IsDir() {
+			continue
+		}
+		if rel, err := filepath.Rel(p, path); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// combineResults builds the result of a whole run from the latest
+// result of each file.
+func combineResults(known map[string]validator.FileResult) validator.Result {
+	var res validator.Result
+	for _, r := range known {
+		res.Add(r)
+	}
+	return res
+}